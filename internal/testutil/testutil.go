@@ -0,0 +1,33 @@
+// 提供测试用的 core.Client, 把所有请求转发到 httptest.Server
+package testutil
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+// StaticTokenServer 是固定返回 "token" 的 core.AccessTokenServer.
+type StaticTokenServer struct{}
+
+func (StaticTokenServer) Token() (string, error)               { return "token", nil }
+func (StaticTokenServer) RefreshToken(string) (string, error)  { return "token", nil }
+func (StaticTokenServer) IID01332E16DF5011E5A9D5A4DB30FED8E1() {}
+
+// RewriteTransport 把请求的 scheme 和 host 改写为 Target(httptest.Server.URL), 保留 path 和 query.
+type RewriteTransport struct {
+	Target string
+}
+
+func (t RewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r2 := r.Clone(r.Context())
+	r2.URL.Scheme = "http"
+	r2.URL.Host = strings.TrimPrefix(t.Target, "http://")
+	return http.DefaultTransport.RoundTrip(r2)
+}
+
+// NewClient 返回的 core.Client 使用 StaticTokenServer, 并把所有请求发送到 serverURL.
+func NewClient(serverURL string) *core.Client {
+	return core.NewClient(StaticTokenServer{}, &http.Client{Transport: RewriteTransport{Target: serverURL}})
+}
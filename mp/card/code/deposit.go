@@ -0,0 +1,104 @@
+package code
+
+import (
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+// DepositMaxCount 是 Deposit 和 CheckCode 每次调用最多可以传入的 code 数量.
+const DepositMaxCount = 100
+
+type DepositResult struct {
+	SuccCode      []string `json:"succ_code"`      // 导入成功的code
+	DuplicateCode []string `json:"duplicate_code"` // 重复导入的code, 会自动被过滤
+	FailCode      []string `json:"fail_code"`      // 导入失败的code
+}
+
+// 导入自定义code(仅对自定义code商户).
+//
+//	cardId: 需要进行导入code的卡券ID
+//	codes:  需导入微信卡券后台的自定义code, 上限为100个
+func Deposit(clt *core.Client, cardId string, codes []string) (rslt *DepositResult, err error) {
+	request := struct {
+		CardId string   `json:"card_id"`
+		Code   []string `json:"code"`
+	}{
+		CardId: cardId,
+		Code:   codes,
+	}
+
+	var result struct {
+		core.Error
+		DepositResult
+	}
+
+	incompleteURL := "https://api.weixin.qq.com/card/code/deposit?access_token="
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	rslt = &result.DepositResult
+	return
+}
+
+// 查询导入code数目.
+func GetDepositCount(clt *core.Client, cardId string) (count int, err error) {
+	request := struct {
+		CardId string `json:"card_id"`
+	}{
+		CardId: cardId,
+	}
+
+	var result struct {
+		core.Error
+		Count int `json:"count"`
+	}
+
+	incompleteURL := "https://api.weixin.qq.com/card/code/getdepositcount?access_token="
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	count = result.Count
+	return
+}
+
+// 核查code, 检查 codes 是否已经成功导入微信后台.
+//
+//	cardId: 进行导入code的卡券ID
+//	codes:  已经微信卡券后台的自定义code, 上限为100个
+func CheckCode(clt *core.Client, cardId string, codes []string) (existCodes, notExistCodes []string, err error) {
+	request := struct {
+		CardId string   `json:"card_id"`
+		Code   []string `json:"code"`
+	}{
+		CardId: cardId,
+		Code:   codes,
+	}
+
+	var result struct {
+		core.Error
+		ExistCode    []string `json:"exist_code"`
+		NotExistCode []string `json:"not_exist_code"`
+	}
+
+	incompleteURL := "https://api.weixin.qq.com/card/code/checkcode?access_token="
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	existCodes = result.ExistCode
+	notExistCodes = result.NotExistCode
+	return
+}
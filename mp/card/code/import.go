@@ -0,0 +1,79 @@
+package code
+
+import (
+	"fmt"
+
+	"github.com/chanxuehong/wechat/mp/card"
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+// ImportResult 是 Import 的结果.
+type ImportResult struct {
+	Imported   []string // 导入成功并且通过 CheckCode 核查的 code
+	Duplicated []string // 重复导入被微信过滤掉的 code
+	Failed     []string // 导入失败的 code, 包括 Deposit 返回失败的和 Deposit 返回成功但是 CheckCode 核查不存在的
+	StockAdded int      // 调用 card.ModifyStock 增加的库存数量, 等于 len(Imported)
+
+	// GetDepositCount 返回的是该卡券累计导入的 code 总数
+	DepositCountBefore int // 导入之前的 code 总数
+	DepositCount       int // 导入完成后的 code 总数
+}
+
+// Import 导入自定义 code 并增加相应的库存.
+//
+//	流程:
+//	1. 调用 GetDepositCount 获取导入之前已导入的 code 总数;
+//	2. 把 codes 按照每次 DepositMaxCount 个分批调用 Deposit 导入;
+//	3. 对每批导入成功的 code 调用 CheckCode 核查;
+//	4. 再次调用 GetDepositCount, 确认 code 总数至少增加了要增加的库存数量, 否则返回错误, 不修改库存;
+//	5. 调用 card.ModifyStock 把库存增加核查通过的 code 数量.
+//
+//	NOTE: 如果中途出错, 返回已经完成的部分结果和错误, 此时库存还没有修改,
+//	调用者可以根据 rslt.Imported 自行调用 card.ModifyStock.
+func Import(clt *core.Client, cardId string, codes []string) (rslt *ImportResult, err error) {
+	rslt = new(ImportResult)
+	if rslt.DepositCountBefore, err = GetDepositCount(clt, cardId); err != nil {
+		return
+	}
+	for len(codes) > 0 {
+		n := len(codes)
+		if n > DepositMaxCount {
+			n = DepositMaxCount
+		}
+		batch := codes[:n]
+		codes = codes[n:]
+
+		var depositResult *DepositResult
+		if depositResult, err = Deposit(clt, cardId, batch); err != nil {
+			return
+		}
+		rslt.Duplicated = append(rslt.Duplicated, depositResult.DuplicateCode...)
+		rslt.Failed = append(rslt.Failed, depositResult.FailCode...)
+		if len(depositResult.SuccCode) == 0 {
+			continue
+		}
+
+		var existCodes, notExistCodes []string
+		if existCodes, notExistCodes, err = CheckCode(clt, cardId, depositResult.SuccCode); err != nil {
+			return
+		}
+		rslt.Imported = append(rslt.Imported, existCodes...)
+		rslt.Failed = append(rslt.Failed, notExistCodes...)
+	}
+
+	if len(rslt.Imported) > 0 {
+		if rslt.DepositCount, err = GetDepositCount(clt, cardId); err != nil {
+			return
+		}
+		if added := rslt.DepositCount - rslt.DepositCountBefore; added < len(rslt.Imported) {
+			err = fmt.Errorf("deposit count increased by %d (%d -> %d), less than the stock to add %d",
+				added, rslt.DepositCountBefore, rslt.DepositCount, len(rslt.Imported))
+			return
+		}
+		if err = card.ModifyStock(clt, cardId, len(rslt.Imported)); err != nil {
+			return
+		}
+		rslt.StockAdded = len(rslt.Imported)
+	}
+	return
+}
@@ -0,0 +1,116 @@
+package code
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+)
+
+// importServer 模拟 code 导入相关的接口: "dup" 开头的 code 重复导入, "fail" 开头的 code 导入失败,
+// "lost" 开头的 code 导入成功但是核查不存在.
+type importServer struct {
+	depositBatches []int // 每次 Deposit 的 code 数量
+	checkBatches   []int // 每次 CheckCode 的 code 数量
+	depositCount   int   // GetDepositCount 返回的数量, 导入成功的 code 会累加上去
+	countStale     bool  // 导入成功之后 depositCount 不增加
+	stockAdded     int   // ModifyStock 增加的库存
+}
+
+func (s *importServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code               []string `json:"code"`
+		IncreaseStockValue int      `json:"increase_stock_value"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	resp := map[string]interface{}{"errcode": 0, "errmsg": "ok"}
+	switch r.URL.Path {
+	case "/card/code/deposit":
+		s.depositBatches = append(s.depositBatches, len(req.Code))
+		var succ, dup, fail []string
+		for _, code := range req.Code {
+			switch {
+			case strings.HasPrefix(code, "dup"):
+				dup = append(dup, code)
+			case strings.HasPrefix(code, "fail"):
+				fail = append(fail, code)
+			default:
+				succ = append(succ, code)
+			}
+		}
+		if !s.countStale {
+			s.depositCount += len(succ)
+		}
+		resp["succ_code"], resp["duplicate_code"], resp["fail_code"] = succ, dup, fail
+	case "/card/code/checkcode":
+		s.checkBatches = append(s.checkBatches, len(req.Code))
+		var exist, notExist []string
+		for _, code := range req.Code {
+			if strings.HasPrefix(code, "lost") {
+				notExist = append(notExist, code)
+			} else {
+				exist = append(exist, code)
+			}
+		}
+		resp["exist_code"], resp["not_exist_code"] = exist, notExist
+	case "/card/code/getdepositcount":
+		resp["count"] = s.depositCount
+	case "/card/modifystock":
+		s.stockAdded += req.IncreaseStockValue
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func TestImport(t *testing.T) {
+	var codes []string
+	for i := 0; i < 247; i++ {
+		codes = append(codes, fmt.Sprintf("code%d", i))
+	}
+	codes = append(codes, "dup1", "fail1", "lost1")
+
+	s := &importServer{depositCount: 1000}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	clt := testutil.NewClient(srv.URL)
+
+	rslt, err := Import(clt, "CARD_ID", codes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{100, 100, 50}; !reflect.DeepEqual(s.depositBatches, want) {
+		t.Errorf("deposit batches = %v, want %v", s.depositBatches, want)
+	}
+	if want := []int{100, 100, 48}; !reflect.DeepEqual(s.checkBatches, want) {
+		t.Errorf("check batches = %v, want %v", s.checkBatches, want)
+	}
+	if len(rslt.Imported) != 247 || rslt.Imported[0] != "code0" || rslt.Imported[246] != "code246" {
+		t.Errorf("len(Imported) = %d", len(rslt.Imported))
+	}
+	if !reflect.DeepEqual(rslt.Duplicated, []string{"dup1"}) || !reflect.DeepEqual(rslt.Failed, []string{"fail1", "lost1"}) {
+		t.Errorf("Duplicated = %v, Failed = %v", rslt.Duplicated, rslt.Failed)
+	}
+	if rslt.StockAdded != 247 || s.stockAdded != 247 || rslt.DepositCountBefore != 1000 || rslt.DepositCount != 1248 {
+		t.Errorf("StockAdded = %d, server stock added = %d, DepositCount = %d -> %d",
+			rslt.StockAdded, s.stockAdded, rslt.DepositCountBefore, rslt.DepositCount)
+	}
+
+	// 已导入的 code 总数是累计值, 之前导入的 code 不能算到这次要增加的库存里
+	s = &importServer{depositCount: 1000, countStale: true}
+	srv2 := httptest.NewServer(s)
+	defer srv2.Close()
+	if rslt, err = Import(testutil.NewClient(srv2.URL), "CARD_ID", []string{"a", "b"}); err == nil {
+		t.Error("want error when deposit count does not increase by the stock to add")
+	}
+	if s.stockAdded != 0 || rslt.StockAdded != 0 || len(rslt.Imported) != 2 {
+		t.Errorf("stock added = %d, rslt = %+v", s.stockAdded, rslt)
+	}
+}
@@ -15,6 +15,7 @@ const (
 	EventTypeUserViewCard             core.EventType = "user_view_card"               // 进入会员卡事件推送
 	EventTypeUserEnterSessionFromCard core.EventType = "user_enter_session_from_card" // 从卡券进入公众号会话事件推送
 	EventTypeCardSkuRemind            core.EventType = "card_sku_remind"              // 库存报警事件
+	EventTypeSubmitMemberCardUserInfo core.EventType = "submit_membercard_user_info"  // 用户提交会员卡激活资料事件推送
 
 	EventTypeGiftCardPayDone    core.EventType = "giftcard_pay_done"    // 用户购买礼品卡付款成功
 	EventTypeGiftCardUserAccept core.EventType = "giftcard_user_accept" // 用户领取礼品卡成功
@@ -204,6 +205,25 @@ func GetUserEnterSessionFromCardEvent(msg *core.MixedMsg) *UserEnterSessionFromC
 	}
 }
 
+// 用户通过一键激活提交会员卡激活资料后, 微信会把这个事件推送到开发者填写的URL
+type SubmitMemberCardUserInfoEvent struct {
+	XMLName struct{} `xml:"xml" json:"-"`
+	core.MsgHeader
+
+	EventType    core.EventType `xml:"Event"        json:"Event"`        // 事件类型, submit_membercard_user_info
+	CardId       string         `xml:"CardId"       json:"CardId"`       // 卡券ID
+	UserCardCode string         `xml:"UserCardCode" json:"UserCardCode"` // 卡券Code码
+}
+
+func GetSubmitMemberCardUserInfoEvent(msg *core.MixedMsg) *SubmitMemberCardUserInfoEvent {
+	return &SubmitMemberCardUserInfoEvent{
+		MsgHeader:    msg.MsgHeader,
+		EventType:    msg.EventType,
+		CardId:       msg.CardId,
+		UserCardCode: msg.UserCardCode,
+	}
+}
+
 // 库存报警事件
 type CardSkuRemindEvent struct {
 	XMLName struct{} `xml:"xml" json:"-"`
@@ -0,0 +1,46 @@
+package giftcard
+
+import (
+	"github.com/chanxuehong/wechat/mp/card"
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+// giftcard_pay_done 事件和对应的订单详情
+type PayDoneEvent struct {
+	*card.GiftCardPayDoneEvent
+	Order *Order
+}
+
+// GetPayDoneEvent 解析 giftcard_pay_done 事件, 并获取对应的订单详情.
+func GetPayDoneEvent(clt *core.Client, msg *core.MixedMsg) (event *PayDoneEvent, err error) {
+	e := card.GetGiftCardPayDoneEvent(msg)
+	order, err := GetOrder(clt, e.OrderId)
+	if err != nil {
+		return
+	}
+	event = &PayDoneEvent{
+		GiftCardPayDoneEvent: e,
+		Order:                order,
+	}
+	return
+}
+
+// giftcard_user_accept 事件和对应的订单详情
+type UserAcceptEvent struct {
+	*card.GiftCardUserAcceptEvent
+	Order *Order
+}
+
+// GetUserAcceptEvent 解析 giftcard_user_accept 事件, 并获取对应的订单详情.
+func GetUserAcceptEvent(clt *core.Client, msg *core.MixedMsg) (event *UserAcceptEvent, err error) {
+	e := card.GetGiftCardUserAcceptEvent(msg)
+	order, err := GetOrder(clt, e.OrderId)
+	if err != nil {
+		return
+	}
+	event = &UserAcceptEvent{
+		GiftCardUserAcceptEvent: e,
+		Order:                   order,
+	}
+	return
+}
@@ -0,0 +1,113 @@
+package giftcard
+
+import (
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+const (
+	SortTypeAsc  = "ASC"  // 升序
+	SortTypeDesc = "DESC" // 降序
+)
+
+// 礼品卡订单
+type Order struct {
+	OrderId        string      `json:"order_id"`                  // 订单号
+	PageId         string      `json:"page_id"`                   // 货架的id
+	TransId        string      `json:"trans_id"`                  // 微信支付交易订单号
+	CreateTime     int64       `json:"create_time"`               // 订单创建时间, 十位时间戳(utc+8)
+	PayFinishTime  int64       `json:"pay_finish_time"`           // 订单支付完成时间, 十位时间戳(utc+8)
+	TotalPrice     int         `json:"total_price"`               // 全部金额, 以分为单位
+	OpenId         string      `json:"open_id"`                   // 购买者的openid
+	AccepterOpenId string      `json:"accepter_openid,omitempty"` // 接收者的openid
+	CardList       []OrderCard `json:"card_list"`                 // 卡列表结构
+	OuterStr       string      `json:"outer_str,omitempty"`       // 购买的链接中带入的自定义参数, 用于数据统计
+}
+
+type OrderCard struct {
+	CardId            string `json:"card_id"`             // 购买的卡券id
+	Price             int    `json:"price"`               // 卡面价格, 以分为单位
+	Code              string `json:"code"`                // 卡券code
+	DefaultGiftingMsg string `json:"default_gifting_msg"` // 祝福语
+	AcceptTime        int64  `json:"accept_time"`         // 领取时间, 十位时间戳(utc+8), 未领取时为 0
+}
+
+// 查询某个订单号对应的订单详情.
+func GetOrder(clt *core.Client, orderId string) (order *Order, err error) {
+	request := struct {
+		OrderId string `json:"order_id"`
+	}{
+		OrderId: orderId,
+	}
+
+	var result struct {
+		core.Error
+		Order Order `json:"order"`
+	}
+
+	incompleteURL := "https://api.weixin.qq.com/card/giftcard/order/get?access_token="
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	order = &result.Order
+	return
+}
+
+type BatchGetOrderQuery struct {
+	BeginTime int64  `json:"begin_time"`          // 查询的时间起点, 十位时间戳(utc+8)
+	EndTime   int64  `json:"end_time"`            // 查询的时间终点, 十位时间戳(utc+8)
+	SortType  string `json:"sort_type,omitempty"` // 填"ASC"/"DESC", 表示对订单创建时间进行"升/降"排序
+	Offset    int    `json:"offset"`              // 查询的订单偏移量, 如填写100则表示从第100个订单开始拉取
+	Count     int    `json:"count"`               // 查询订单的数量, 如offset填写100, count填写10, 则表示查询第100个到第110个订单
+}
+
+type BatchGetOrderResult struct {
+	TotalCount int     `json:"total_count"` // 订单总数
+	OrderList  []Order `json:"order_list"`
+}
+
+// 批量查询礼品卡订单信息接口.
+func BatchGetOrder(clt *core.Client, query *BatchGetOrderQuery) (rslt *BatchGetOrderResult, err error) {
+	var result struct {
+		core.Error
+		BatchGetOrderResult
+	}
+
+	incompleteURL := "https://api.weixin.qq.com/card/giftcard/order/batchget?access_token="
+	if err = clt.PostJSON(incompleteURL, query, &result); err != nil {
+		return
+	}
+
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	rslt = &result.BatchGetOrderResult
+	return
+}
+
+// 对一笔礼品卡订单操作退款.
+func RefundOrder(clt *core.Client, orderId string) (err error) {
+	request := struct {
+		OrderId string `json:"order_id"`
+	}{
+		OrderId: orderId,
+	}
+
+	var result core.Error
+
+	incompleteURL := "https://api.weixin.qq.com/card/giftcard/order/refund?access_token="
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result
+		return
+	}
+	return
+}
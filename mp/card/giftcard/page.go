@@ -0,0 +1,152 @@
+package giftcard
+
+import (
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+// 礼品卡货架
+type Page struct {
+	PageId            string     `json:"page_id,omitempty"`       // 货架id, 创建时不需要填写
+	PageTitle         string     `json:"page_title"`              // 必须; 礼品卡货架名称
+	SupportMulti      bool       `json:"support_multi"`           // 可选; 是否支持一次购买多张及发送至群, 填true或者false, 若填true则支持, 默认为false
+	SupportBuyForSelf bool       `json:"support_buy_for_self"`    // 可选; 礼品卡货架是否支持买给自己, 填true或者false, 若填true则支持, 默认为false
+	BannerPicURL      string     `json:"banner_pic_url"`          // 必须; 礼品卡货架主题页顶部banner图片, 须先将图片上传至CDN, 建议尺寸为750px*630px
+	ThemeList         []Theme    `json:"theme_list"`              // 必须; 主题结构体, 是一个JSON结构
+	CategoryList      []Category `json:"category_list,omitempty"` // 可选; 主题分类列表
+	Address           string     `json:"address"`                 // 必须; 商家地址
+	ServicePhone      string     `json:"service_phone"`           // 必须; 商家服务电话
+	BizDescription    string     `json:"biz_description"`         // 必须; 商家使用说明, 用于描述退款、发票等流程
+	NeedReceipt       bool       `json:"need_receipt"`            // 可选; 该货架的订单是否支持开发票, 填true或者false, 默认为false
+	Cell1             *Cell      `json:"cell_1,omitempty"`        // 可选; 商家自定义链接, 用于承载退款、发票等流程
+	Cell2             *Cell      `json:"cell_2,omitempty"`        // 可选; 商家自定义链接, 用于承载退款、发票等流程
+}
+
+type Theme struct {
+	ThemePicURL       string      `json:"theme_pic_url"`                  // 必须; 主题的封面图片, 须先将图片上传至CDN, 大小控制在1000px*600px
+	Title             string      `json:"title"`                          // 必须; 主题名称, 如"圣诞", "感恩家人"
+	TitleColor        string      `json:"title_color"`                    // 必须; 主题title的颜色, 直接传入色值
+	ItemList          []ThemeItem `json:"item_list"`                      // 必须; 礼品卡列表, 标识该主题可选择的面额
+	PicItemList       []PicItem   `json:"pic_item_list"`                  // 必须; 封面列表
+	CategoryIndex     int         `json:"category_index,omitempty"`       // 可选; 当前主题所属的类别, 用于礼品卡货架分类, 从 1 开始
+	ShowSkuTitleFirst bool        `json:"show_sku_title_first,omitempty"` // 可选; 该主题购买页是否突出商品名显示
+	IsBanner          bool        `json:"is_banner,omitempty"`            // 可选; 是否将当前主题设置为banner主题(主推)
+}
+
+type ThemeItem struct {
+	CardId string `json:"card_id"`         // 必须; 待上架的card_id
+	Title  string `json:"title,omitempty"` // 可选; 商品名, 不填写默认为卡名称
+}
+
+type PicItem struct {
+	BackgroundPicURL  string `json:"background_pic_url"`  // 必须; 卡面图片, 须先将图片上传至CDN, 大小控制在1000像素*600像素以下
+	OuterImgId        string `json:"outer_img_id"`        // 必须; 自定义的卡面的标识
+	DefaultGiftingMsg string `json:"default_gifting_msg"` // 必须; 该卡面对应的默认祝福语, 当用户没有编辑内容时会随卡默认填写为用户祝福内容
+}
+
+type Category struct {
+	Title string `json:"title"` // 分类名称
+}
+
+type Cell struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// 创建礼品卡货架接口.
+func AddPage(clt *core.Client, page *Page) (pageId string, err error) {
+	request := struct {
+		Page *Page `json:"page"`
+	}{
+		Page: page,
+	}
+
+	var result struct {
+		core.Error
+		PageId string `json:"page_id"`
+	}
+
+	incompleteURL := "https://api.weixin.qq.com/card/giftcard/page/add?access_token="
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	pageId = result.PageId
+	return
+}
+
+// 修改礼品卡货架信息接口, page.PageId 必须填写.
+func UpdatePage(clt *core.Client, page *Page) (err error) {
+	request := struct {
+		Page *Page `json:"page"`
+	}{
+		Page: page,
+	}
+
+	var result core.Error
+
+	incompleteURL := "https://api.weixin.qq.com/card/giftcard/page/update?access_token="
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result
+		return
+	}
+	return
+}
+
+// 查询礼品卡货架信息接口.
+func GetPage(clt *core.Client, pageId string) (page *Page, err error) {
+	request := struct {
+		PageId string `json:"page_id"`
+	}{
+		PageId: pageId,
+	}
+
+	var result struct {
+		core.Error
+		Page Page `json:"page"`
+	}
+
+	incompleteURL := "https://api.weixin.qq.com/card/giftcard/page/get?access_token="
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	if result.Page.PageId == "" {
+		result.Page.PageId = pageId
+	}
+	page = &result.Page
+	return
+}
+
+// 查询礼品卡货架列表接口.
+func BatchGetPage(clt *core.Client) (pageIdList []string, err error) {
+	var request struct{}
+
+	var result struct {
+		core.Error
+		PageIdList []string `json:"page_id_list"`
+	}
+
+	incompleteURL := "https://api.weixin.qq.com/card/giftcard/page/batchget?access_token="
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	pageIdList = result.PageIdList
+	return
+}
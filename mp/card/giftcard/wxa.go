@@ -0,0 +1,32 @@
+package giftcard
+
+import (
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+// 申请礼品卡的小程序, 将小程序和礼品卡货架绑定, 使用户可以在小程序内购买礼品卡.
+//
+//	wxaAppId: 小程序的appid
+//	pageId:   货架的id
+func SetWxa(clt *core.Client, wxaAppId, pageId string) (err error) {
+	request := struct {
+		WxaAppId string `json:"wxa_appid"`
+		PageId   string `json:"page_id"`
+	}{
+		WxaAppId: wxaAppId,
+		PageId:   pageId,
+	}
+
+	var result core.Error
+
+	incompleteURL := "https://api.weixin.qq.com/card/giftcard/wxa/set?access_token="
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result
+		return
+	}
+	return
+}
@@ -0,0 +1,140 @@
+package card
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+// BatchGetMaxCount 是 BatchGetQuery.Count 的最大值.
+const BatchGetMaxCount = 50
+
+// CardIterator
+//
+//	iter, err := NewCardIterator(clt, &BatchGetQuery{Offset: 0, Count: 50})
+//	if err != nil {
+//	    // TODO: 增加你的代码
+//	}
+//
+//	for iter.HasNext() {
+//	    cardIds, err := iter.NextPage()
+//	    if err != nil {
+//	        // TODO: 增加你的代码
+//	    }
+//	    // TODO: 增加你的代码
+//	}
+type CardIterator struct {
+	clt *core.Client
+
+	query BatchGetQuery // query.Offset 为下一页的起始偏移量
+
+	lastBatchGetResult *BatchGetResult
+	nextPageCalled     bool
+}
+
+func (iter *CardIterator) TotalCount() int {
+	return iter.lastBatchGetResult.TotalNum
+}
+
+func (iter *CardIterator) HasNext() bool {
+	if !iter.nextPageCalled {
+		return iter.lastBatchGetResult.ItemNum > 0 || iter.query.Offset < iter.lastBatchGetResult.TotalNum
+	}
+	return iter.query.Offset < iter.lastBatchGetResult.TotalNum
+}
+
+func (iter *CardIterator) NextPage() (cardIdList []string, err error) {
+	if !iter.nextPageCalled {
+		iter.nextPageCalled = true
+		cardIdList = iter.lastBatchGetResult.CardIdList
+		return
+	}
+
+	rslt, err := BatchGet(iter.clt, &iter.query)
+	if err != nil {
+		return
+	}
+
+	iter.lastBatchGetResult = rslt
+	iter.query.Offset += rslt.ItemNum
+
+	cardIdList = rslt.CardIdList
+	return
+}
+
+// NewCardIterator 获取卡券遍历器, 从 query.Offset 开始遍历, 每页 query.Count 个卡券ID.
+//
+//	query.Count: 每页的数量, 如果 == 0 则默认为 BatchGetMaxCount, 最大值为 BatchGetMaxCount
+func NewCardIterator(clt *core.Client, query *BatchGetQuery) (iter *CardIterator, err error) {
+	q := *query
+	if q.Offset < 0 {
+		err = fmt.Errorf("invalid offset: %d", q.Offset)
+		return
+	}
+	switch {
+	case q.Count == 0:
+		q.Count = BatchGetMaxCount
+	case q.Count < 0 || q.Count > BatchGetMaxCount:
+		err = fmt.Errorf("invalid count: %d", q.Count)
+		return
+	}
+
+	// 逻辑上相当于第一次调用 CardIterator.NextPage,
+	// 因为第一次调用 CardIterator.HasNext 需要数据支撑, 所以提前获取了数据
+	rslt, err := BatchGet(clt, &q)
+	if err != nil {
+		return
+	}
+	q.Offset += rslt.ItemNum
+
+	iter = &CardIterator{
+		clt:                clt,
+		query:              q,
+		lastBatchGetResult: rslt,
+		nextPageCalled:     false,
+	}
+	return
+}
+
+// =====================================================================================================================
+
+// GetMulti 并发的查询 cardIdList 中每个卡券的详情, 返回的 cardList 和 cardIdList 一一对应.
+//
+//	concurrency: 并发数, 如果 <= 0 则默认为 8
+//	NOTE: 任何一个卡券查询失败都会返回 err, 此时 cardList 中查询成功的卡券依然有效, 失败的为 nil.
+func GetMulti(clt *core.Client, cardIdList []string, concurrency int) (cardList []*Card, err error) {
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+	if concurrency > len(cardIdList) {
+		concurrency = len(cardIdList)
+	}
+	cardList = make([]*Card, len(cardIdList))
+
+	var (
+		wg      sync.WaitGroup
+		errOnce sync.Once
+		indexes = make(chan int)
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				card, err2 := Get(clt, cardIdList[index])
+				if err2 != nil {
+					errOnce.Do(func() { err = err2 })
+					continue
+				}
+				cardList[index] = card
+			}
+		}()
+	}
+	for i := range cardIdList {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return
+}
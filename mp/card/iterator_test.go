@@ -0,0 +1,51 @@
+package card
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+)
+
+func TestCardIterator(t *testing.T) {
+	const total = 7
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var query BatchGetQuery
+		json.Unmarshal(body, &query)
+		var ids []string
+		for i := query.Offset; i < total && i < query.Offset+query.Count; i++ {
+			ids = append(ids, string(rune('a'+i)))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"errcode": 0, "total_num": total, "card_id_list": ids})
+	}))
+	defer srv.Close()
+
+	clt := testutil.NewClient(srv.URL)
+	iter, err := NewCardIterator(clt, &BatchGetQuery{Count: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iter.TotalCount() != total {
+		t.Fatalf("have TotalCount %d, want %d", iter.TotalCount(), total)
+	}
+	var all []string
+	for iter.HasNext() {
+		ids, err := iter.NextPage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, ids...)
+	}
+	if have := strings.Join(all, ""); have != "abcdefg" {
+		t.Errorf("have %q, want %q", have, "abcdefg")
+	}
+
+	if _, err = NewCardIterator(clt, &BatchGetQuery{Count: BatchGetMaxCount + 1}); err == nil {
+		t.Error("want error for count > BatchGetMaxCount")
+	}
+}
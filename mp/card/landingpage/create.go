@@ -0,0 +1,51 @@
+package landingpage
+
+import (
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+const (
+	// 投放页面的场景值
+	SceneNearBy         = "SCENE_NEAR_BY"          // 附近
+	SceneMenu           = "SCENE_MENU"             // 自定义菜单
+	SceneQrcode         = "SCENE_QRCODE"           // 二维码
+	SceneArticle        = "SCENE_ARTICLE"          // 公众号文章
+	SceneH5             = "SCENE_H5"               // h5页面
+	SceneIVR            = "SCENE_IVR"              // 自动回复
+	SceneCardCustomCell = "SCENE_CARD_CUSTOM_CELL" // 卡券自定义cell
+)
+
+type CreateParameters struct {
+	Banner    string `json:"banner"`     // 必须; 页面的banner图片链接, 须调用上传图片接口获得, 建议尺寸为640*300.
+	PageTitle string `json:"page_title"` // 必须; 页面的title.
+	CanShare  bool   `json:"can_share"`  // 必须; 页面是否可以分享, 填入true/false
+	Scene     string `json:"scene"`      // 必须; 投放页面的场景值, SceneXXX
+	CardList  []Card `json:"card_list"`  // 必须; 卡券列表, 每个item有两个字段
+}
+
+type Card struct {
+	CardId   string `json:"card_id"`   // 必须; 所要在页面投放的card_id
+	ThumbURL string `json:"thumb_url"` // 必须; 缩略图url
+}
+
+// 创建货架(卡券投放页面)接口, 可以在一个页面投放多张卡券.
+func Create(clt *core.Client, para *CreateParameters) (url string, pageId int64, err error) {
+	var result struct {
+		core.Error
+		URL    string `json:"url"`
+		PageId int64  `json:"page_id"`
+	}
+
+	incompleteURL := "https://api.weixin.qq.com/card/landingpage/create?access_token="
+	if err = clt.PostJSON(incompleteURL, para, &result); err != nil {
+		return
+	}
+
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	url = result.URL
+	pageId = result.PageId
+	return
+}
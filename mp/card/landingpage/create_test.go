@@ -0,0 +1,42 @@
+package landingpage
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+)
+
+func TestCreate(t *testing.T) {
+	var have CreateParameters
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/card/landingpage/create" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&have)
+		io.WriteString(w, `{"errcode":0,"url":"www.test.url","page_id":1}`)
+	}))
+	defer srv.Close()
+
+	para := &CreateParameters{
+		Banner:    "http://mmbiz.qpic.cn/banner",
+		PageTitle: "惠城优惠大派送",
+		CanShare:  true,
+		Scene:     SceneIVR,
+		CardList:  []Card{{CardId: "p1Pj9jr90_SQRaVqYI239Ka1erkI", ThumbURL: "www.qq.com/a.jpg"}},
+	}
+	url, pageId, err := Create(testutil.NewClient(srv.URL), para)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "www.test.url" || pageId != 1 {
+		t.Errorf("url = %q, pageId = %d", url, pageId)
+	}
+	if !reflect.DeepEqual(&have, para) {
+		t.Errorf("request = %+v, want %+v", have, *para)
+	}
+}
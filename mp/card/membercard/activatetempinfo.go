@@ -0,0 +1,119 @@
+package membercard
+
+import (
+	"strings"
+
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+// 用户填写的激活资料
+type FormInfo struct {
+	CommonFieldList []FormField `json:"common_field_list,omitempty"` // 通用选项, FormField.Name 为 UserFormInfoFlagXXX
+	CustomFieldList []FormField `json:"custom_field_list,omitempty"` // 自定义选项
+}
+
+type FormField struct {
+	Name      string   `json:"name"`
+	Value     string   `json:"value,omitempty"`
+	ValueList []string `json:"value_list,omitempty"` // 多选的值
+}
+
+// 获取用户提交资料(跳转型一键激活).
+//
+//	activateTicket: 用户填写并提交开卡资料后, 跳转到 activate_url 时带上的 activate_ticket 参数.
+func GetActivateTempInfo(clt *core.Client, activateTicket string) (info *FormInfo, err error) {
+	request := struct {
+		ActivateTicket string `json:"activate_ticket"`
+	}{
+		ActivateTicket: activateTicket,
+	}
+
+	var result struct {
+		core.Error
+		Info FormInfo `json:"info"`
+	}
+
+	incompleteURL := "https://api.weixin.qq.com/card/membercard/activatetempinfo/get?access_token="
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	info = &result.Info
+	return
+}
+
+// 用户填写的激活资料, 通用选项已经解析到对应的字段.
+type FormValues struct {
+	Mobile              string
+	Sex                 string
+	Name                string
+	Birthday            string
+	IdCard              string
+	Email               string
+	Location            string
+	EducationBackground string
+	Industry            string
+	Income              string
+	Habit               string
+
+	Custom map[string][]string // 自定义选项, key 为选项名称
+}
+
+// ParseFormInfo 把用户填写的激活资料解析为 FormValues, 多选的通用选项的值用逗号(,)连接.
+func ParseFormInfo(info *FormInfo) *FormValues {
+	values := &FormValues{
+		Custom: make(map[string][]string, len(info.CustomFieldList)),
+	}
+	for _, field := range info.CommonFieldList {
+		value := field.Value
+		if value == "" && len(field.ValueList) > 0 {
+			value = strings.Join(field.ValueList, ",")
+		}
+		switch field.Name {
+		case UserFormInfoFlagMobile:
+			values.Mobile = value
+		case UserFormInfoFlagSex:
+			values.Sex = value
+		case UserFormInfoFlagName:
+			values.Name = value
+		case UserFormInfoFlagBirthday:
+			values.Birthday = value
+		case UserFormInfoFlagIdCard:
+			values.IdCard = value
+		case UserFormInfoFlagEmail:
+			values.Email = value
+		case UserFormInfoFlagLocation:
+			values.Location = value
+		case UserFormInfoFlagEducationBackground:
+			values.EducationBackground = value
+		case UserFormInfoFlagIndustry:
+			values.Industry = value
+		case UserFormInfoFlagIncome:
+			values.Income = value
+		case UserFormInfoFlagHabit:
+			values.Habit = value
+		}
+	}
+	for _, field := range info.CustomFieldList {
+		if len(field.ValueList) > 0 {
+			values.Custom[field.Name] = field.ValueList
+		} else {
+			values.Custom[field.Name] = []string{field.Value}
+		}
+	}
+	return values
+}
+
+// GetActivateFormValues 用 activateTicket 获取用户填写的激活资料, 并解析为 FormValues.
+func GetActivateFormValues(clt *core.Client, activateTicket string) (values *FormValues, err error) {
+	info, err := GetActivateTempInfo(clt, activateTicket)
+	if err != nil {
+		return
+	}
+	values = ParseFormInfo(info)
+	return
+}
@@ -0,0 +1,69 @@
+package membercard
+
+import (
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+const (
+	// 会员卡激活时的通用选项(官方预设的字段)
+	UserFormInfoFlagMobile              = "USER_FORM_INFO_FLAG_MOBILE"               // 手机号
+	UserFormInfoFlagSex                 = "USER_FORM_INFO_FLAG_SEX"                  // 性别
+	UserFormInfoFlagName                = "USER_FORM_INFO_FLAG_NAME"                 // 姓名
+	UserFormInfoFlagBirthday            = "USER_FORM_INFO_FLAG_BIRTHDAY"             // 生日
+	UserFormInfoFlagIdCard              = "USER_FORM_INFO_FLAG_IDCARD"               // 身份证
+	UserFormInfoFlagEmail               = "USER_FORM_INFO_FLAG_EMAIL"                // 邮箱
+	UserFormInfoFlagLocation            = "USER_FORM_INFO_FLAG_LOCATION"             // 详细地址
+	UserFormInfoFlagEducationBackground = "USER_FORM_INFO_FLAG_EDUCATION_BACKGROUND" // 教育背景
+	UserFormInfoFlagIndustry            = "USER_FORM_INFO_FLAG_INDUSTRY"             // 行业
+	UserFormInfoFlagIncome              = "USER_FORM_INFO_FLAG_INCOME"               // 收入
+	UserFormInfoFlagHabit               = "USER_FORM_INFO_FLAG_HABIT"                // 兴趣爱好
+)
+
+const (
+	// 自定义富文本类型
+	FormFieldRadio    = "FORM_FIELD_RADIO"     // 自定义单选
+	FormFieldSelect   = "FORM_FIELD_SELECT"    // 自定义选择项
+	FormFieldCheckBox = "FORM_FIELD_CHECK_BOX" // 自定义多选
+)
+
+type ActivateUserForm struct {
+	CardId           string    `json:"card_id"`                     // 必填; 卡券ID
+	ServiceStatement *FormLink `json:"service_statement,omitempty"` // 可选; 服务声明, 用于放置商户会员卡守则
+	BindOldCard      *FormLink `json:"bind_old_card,omitempty"`     // 可选; 绑定老会员链接
+	RequiredForm     *UserForm `json:"required_form,omitempty"`     // 可选; 会员卡激活时的必填选项
+	OptionalForm     *UserForm `json:"optional_form,omitempty"`     // 可选; 会员卡激活时的选填项
+}
+
+type FormLink struct {
+	Name string `json:"name"` // 链接名称
+	URL  string `json:"url"`  // 自定义url, 请填写http:// 或者https://开头的链接
+}
+
+type UserForm struct {
+	CanModify         bool        `json:"can_modify"`                     // 当前结构(required_form 或者 optional_form)内的字段是否允许用户激活后再次修改
+	CommonFieldIdList []string    `json:"common_field_id_list,omitempty"` // 微信格式化的选项类型, UserFormInfoFlagXXX
+	CustomFieldList   []string    `json:"custom_field_list,omitempty"`    // 自定义选项名称, 开发者可以分别在必填和选填中至多定义五个自定义选项
+	RichFieldList     []RichField `json:"rich_field_list,omitempty"`      // 自定义富文本类型, 包含以下三个字段, 开发者可以分别在必填和选填中至多定义五个自定义选项
+}
+
+type RichField struct {
+	Type   string   `json:"type"`   // 富文本类型, FormFieldXXX
+	Name   string   `json:"name"`   // 字段名
+	Values []string `json:"values"` // 选择项
+}
+
+// 设置开卡字段接口(一键激活).
+func SetActivateUserForm(clt *core.Client, form *ActivateUserForm) (err error) {
+	var result core.Error
+
+	incompleteURL := "https://api.weixin.qq.com/card/membercard/activateuserform/set?access_token="
+	if err = clt.PostJSON(incompleteURL, form, &result); err != nil {
+		return
+	}
+
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result
+		return
+	}
+	return
+}
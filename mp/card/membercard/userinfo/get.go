@@ -2,6 +2,7 @@ package userinfo
 
 import (
 	"github.com/chanxuehong/wechat/mp/card/code"
+	"github.com/chanxuehong/wechat/mp/card/membercard"
 	"github.com/chanxuehong/wechat/mp/core"
 )
 
@@ -11,10 +12,15 @@ type CustomField struct {
 }
 
 type UserInfo struct {
-	OpenID          string        `json:"openid"`
-	Nickname        string        `json:"nickname"`
-	Sex             string        `json:"sex"`
-	CustomFieldList []CustomField `json:"custom_field_list"`
+	OpenID           string              `json:"openid"`
+	Nickname         string              `json:"nickname"`
+	MembershipNumber string              `json:"membership_number,omitempty"` // 会员卡编号
+	Bonus            int                 `json:"bonus"`                       // 积分信息
+	Sex              string              `json:"sex"`
+	CustomFieldList  []CustomField       `json:"custom_field_list"`
+	FormInfo         membercard.FormInfo `json:"user_info"`                  // 用户激活时填写的资料
+	UserCardStatus   string              `json:"user_card_status,omitempty"` // 当前用户会员卡状态, NORMAL, EXPIRE, GIFTING, GIFT_SUCC, GIFT_TIMEOUT, DELETE, UNAVAILABLE
+	HasActive        bool                `json:"has_active"`                 // 该卡是否已经被激活
 }
 
 // 拉取会员信息（积分查询）接口
@@ -36,3 +42,16 @@ func Get(clt *core.Client, id *code.CardItemIdentifier) (info *UserInfo, err err
 	info = &result.UserInfo
 	return
 }
+
+// GetFormValues 获取用户激活会员卡时填写的资料, 一般在收到 submit_membercard_user_info 事件后调用:
+//
+//	event := card.GetSubmitMemberCardUserInfoEvent(ctx.MixedMsg)
+//	values, err := userinfo.GetFormValues(clt, &code.CardItemIdentifier{Code: event.UserCardCode, CardId: event.CardId})
+func GetFormValues(clt *core.Client, id *code.CardItemIdentifier) (values *membercard.FormValues, err error) {
+	info, err := Get(clt, id)
+	if err != nil {
+		return
+	}
+	values = membercard.ParseFormInfo(&info.FormInfo)
+	return
+}
@@ -2,6 +2,7 @@ package mpnews
 
 import (
 	"github.com/chanxuehong/wechat/mp/core"
+	"github.com/chanxuehong/wechat/mp/media"
 )
 
 // 获取卡券嵌入图文消息的标准格式代码.
@@ -31,3 +32,17 @@ func GetHTML(clt *core.Client, cardId string) (content string, err error) {
 	content = result.Content
 	return
 }
+
+// UploadNews 把卡券嵌入图文消息的代码追加到 article.Content 后面, 然后创建图文消息素材,
+// 返回的 media_id 可以直接用于群发, 比如 mass2all.NewNews(info.MediaId).
+//
+//	NOTE: article 不会被修改.
+func UploadNews(clt *core.Client, cardId string, article *media.Article) (info *media.MediaInfo, err error) {
+	content, err := GetHTML(clt, cardId)
+	if err != nil {
+		return
+	}
+	articleCopy := *article
+	articleCopy.Content += content
+	return media.UploadNews(clt, &media.News{Articles: []media.Article{articleCopy}})
+}
@@ -0,0 +1,43 @@
+package mpnews
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+	"github.com/chanxuehong/wechat/mp/media"
+)
+
+func TestUploadNews(t *testing.T) {
+	var news media.News
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/card/mpnews/gethtml":
+			io.WriteString(w, `{"errcode":0,"content":"<iframe class=\"res_iframe card_iframe\"></iframe>"}`)
+		case "/cgi-bin/media/uploadnews":
+			json.NewDecoder(r.Body).Decode(&news)
+			io.WriteString(w, `{"type":"news","media_id":"MEDIA_ID","created_at":1391857799}`)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	article := &media.Article{ThumbMediaId: "THUMB", Title: "title", Content: "<p>hello</p>"}
+	info, err := UploadNews(testutil.NewClient(srv.URL), "CARD_ID", article)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.MediaId != "MEDIA_ID" {
+		t.Errorf("info = %+v", info)
+	}
+	if len(news.Articles) != 1 || news.Articles[0].Content != `<p>hello</p><iframe class="res_iframe card_iframe"></iframe>` {
+		t.Errorf("news = %+v", news)
+	}
+	if article.Content != "<p>hello</p>" {
+		t.Error("article modified")
+	}
+}
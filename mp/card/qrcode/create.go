@@ -1,6 +1,7 @@
 package qrcode
 
 import (
+	"fmt"
 	"net/url"
 
 	"github.com/chanxuehong/wechat/mp/core"
@@ -22,7 +23,8 @@ type CreateParameters struct {
 type QrcodeInfo struct {
 	Ticket        string `json:"ticket"`
 	URL           string `json:"url"`
-	ExpireSeconds int    `json:"expire_seconds"` // 0 表示永久二维码
+	ShowQrcodeURL string `json:"show_qrcode_url"` // 二维码显示地址, 点击后跳转二维码页面
+	ExpireSeconds int    `json:"expire_seconds"`  // 0 表示永久二维码
 }
 
 // 卡券投放, 创建二维码接口.
@@ -56,3 +58,52 @@ func Create(clt *core.Client, para *CreateParameters) (info *QrcodeInfo, err err
 	info = &result.QrcodeInfo
 	return
 }
+
+// MultipleCardMaxCount 是 CreateMultiple 一个二维码最多可以包含的卡券数量.
+const MultipleCardMaxCount = 5
+
+// 卡券投放, 创建一个包含多张卡券的二维码.
+//
+//	cards:         卡券列表, 1 到 MultipleCardMaxCount 张, 每一项的 ExpireSeconds 字段被忽略
+//	expireSeconds: 二维码的有效时间, 范围是60 ~ 1800秒, 0 表示永久有效
+func CreateMultiple(clt *core.Client, cards []CreateParameters, expireSeconds int) (info *QrcodeInfo, err error) {
+	if len(cards) == 0 || len(cards) > MultipleCardMaxCount {
+		err = fmt.Errorf("invalid number of cards: %d, must be between 1 and %d", len(cards), MultipleCardMaxCount)
+		return
+	}
+
+	request := struct {
+		ActionName    string `json:"action_name"`
+		ExpireSeconds int    `json:"expire_seconds,omitempty"`
+		ActionInfo    struct {
+			MultipleCard struct {
+				CardList []CreateParameters `json:"card_list"`
+			} `json:"multiple_card"`
+		} `json:"action_info"`
+	}{
+		ActionName:    "QR_MULTIPLE_CARD",
+		ExpireSeconds: expireSeconds,
+	}
+	request.ActionInfo.MultipleCard.CardList = make([]CreateParameters, len(cards))
+	for i := range cards {
+		request.ActionInfo.MultipleCard.CardList[i] = cards[i]
+		request.ActionInfo.MultipleCard.CardList[i].ExpireSeconds = 0
+	}
+
+	var result struct {
+		core.Error
+		QrcodeInfo
+	}
+
+	incompleteURL := "https://api.weixin.qq.com/card/qrcode/create?access_token="
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	info = &result.QrcodeInfo
+	return
+}
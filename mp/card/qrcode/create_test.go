@@ -0,0 +1,58 @@
+package qrcode
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+)
+
+func TestCreateMultiple(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, string(body))
+		io.WriteString(w, `{"errcode":0,"ticket":"TICKET","url":"URL","show_qrcode_url":"SHOW","expire_seconds":1800}`)
+	}))
+	defer srv.Close()
+	clt := testutil.NewClient(srv.URL)
+
+	cards := []CreateParameters{{CardId: "a", ExpireSeconds: 60}, {CardId: "b"}}
+	info, err := CreateMultiple(clt, cards, 1800)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Ticket != "TICKET" || info.ShowQrcodeURL != "SHOW" || info.ExpireSeconds != 1800 {
+		t.Errorf("info = %+v", info)
+	}
+	if cards[0].ExpireSeconds != 60 {
+		t.Error("cards modified")
+	}
+	want := `{"action_name":"QR_MULTIPLE_CARD","expire_seconds":1800,"action_info":{"multiple_card":{"card_list":[{"card_id":"a"},{"card_id":"b"}]}}}`
+	if len(requests) != 1 || !jsonEqual(requests[0], want) {
+		t.Errorf("requests = %v, want %s", requests, want)
+	}
+
+	for _, n := range []int{0, MultipleCardMaxCount + 1} {
+		if _, err = CreateMultiple(clt, make([]CreateParameters, n), 0); err == nil {
+			t.Errorf("want error for %d cards", n)
+		}
+	}
+	if len(requests) != 1 {
+		t.Errorf("invalid cards should not be sent: %v", requests[1:])
+	}
+}
+
+func jsonEqual(a, b string) bool {
+	var x, y interface{}
+	if json.Unmarshal([]byte(a), &x) != nil || json.Unmarshal([]byte(b), &y) != nil {
+		return false
+	}
+	xb, _ := json.Marshal(x)
+	yb, _ := json.Marshal(y)
+	return string(xb) == string(yb)
+}
@@ -0,0 +1,268 @@
+// 卡券领取/核销/删除事件与卡券数据统计接口的对账工具.
+//
+//	用法:
+//	1. 把 Aggregator 注册为事件中间件, 统计每张卡券每天的事件数量:
+//	       mux.UseForEvent(aggregator)
+//	2. 每天(数据统计接口只能拉取昨日及以前的数据)调用 Reconcile 和数据统计接口的数据对账, 发现丢失的事件推送.
+package reconcile
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chanxuehong/wechat/mp/card"
+	"github.com/chanxuehong/wechat/mp/core"
+	datacube "github.com/chanxuehong/wechat/mp/datacube/card"
+	"github.com/chanxuehong/wechat/util"
+)
+
+// Key 标识一张卡券(CardId)在某一天(Date)的统计数据.
+type Key struct {
+	CardId string
+	Date   string // YYYY-MM-DD, 北京时间
+}
+
+// Counts 是根据事件推送统计的数量.
+type Counts struct {
+	Get     int // user_get_card 事件数量, 对应数据统计的领取次数
+	Consume int // user_consume_card 事件数量, 对应数据统计的使用次数
+	Del     int // user_del_card 事件数量, 数据统计接口没有对应的字段
+}
+
+type eventId struct {
+	fromUserName string
+	createTime   int64
+	eventType    core.EventType
+	cardId       string
+	userCardCode string
+}
+
+var _ core.Handler = (*Aggregator)(nil)
+
+// Aggregator 按照卡券和日期统计 user_get_card, user_consume_card, user_del_card 事件的数量.
+//
+//	微信服务器会重试推送事件, Aggregator 会根据 FromUserName, CreateTime, Event, CardId, UserCardCode 去重.
+type Aggregator struct {
+	mu     sync.Mutex
+	counts map[Key]*Counts
+	seen   map[string]map[eventId]struct{} // date -> eventId set
+}
+
+func NewAggregator() *Aggregator {
+	return &Aggregator{
+		counts: make(map[Key]*Counts),
+		seen:   make(map[string]map[eventId]struct{}),
+	}
+}
+
+// ServeMsg 实现 core.Handler 接口, 一般作为事件中间件使用, 只做统计不回复消息.
+func (agg *Aggregator) ServeMsg(ctx *core.Context) {
+	agg.Add(ctx.MixedMsg)
+}
+
+// Add 统计一个事件, 如果不是需要统计的事件或者是重复的事件返回 false.
+func (agg *Aggregator) Add(msg *core.MixedMsg) bool {
+	if msg.MsgType != "event" {
+		return false
+	}
+	var id eventId
+	switch msg.EventType {
+	case card.EventTypeUserGetCard:
+		event := card.GetUserGetCardEvent(msg)
+		id = eventId{event.FromUserName, event.CreateTime, event.EventType, event.CardId, event.UserCardCode}
+	case card.EventTypeUserConsumeCard:
+		event := card.GetUserConsumeCardEvent(msg)
+		id = eventId{event.FromUserName, event.CreateTime, event.EventType, event.CardId, event.UserCardCode}
+	case card.EventTypeUserDelCard:
+		event := card.GetUserDelCardEvent(msg)
+		id = eventId{event.FromUserName, event.CreateTime, event.EventType, event.CardId, event.UserCardCode}
+	default:
+		return false
+	}
+	return agg.add(id)
+}
+
+func (agg *Aggregator) add(id eventId) bool {
+	date := time.Unix(id.createTime, 0).In(util.BeijingLocation).Format("2006-01-02")
+
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+
+	seen := agg.seen[date]
+	if seen == nil {
+		seen = make(map[eventId]struct{})
+		agg.seen[date] = seen
+	}
+	if _, ok := seen[id]; ok {
+		return false
+	}
+	seen[id] = struct{}{}
+
+	key := Key{CardId: id.cardId, Date: date}
+	counts := agg.counts[key]
+	if counts == nil {
+		counts = new(Counts)
+		agg.counts[key] = counts
+	}
+	switch id.eventType {
+	case card.EventTypeUserGetCard:
+		counts.Get++
+	case card.EventTypeUserConsumeCard:
+		counts.Consume++
+	case card.EventTypeUserDelCard:
+		counts.Del++
+	}
+	return true
+}
+
+// Counts 返回 cardId 在 date(YYYY-MM-DD) 这一天的统计数量.
+func (agg *Aggregator) Counts(cardId, date string) Counts {
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+
+	if counts := agg.counts[Key{CardId: cardId, Date: date}]; counts != nil {
+		return *counts
+	}
+	return Counts{}
+}
+
+// Snapshot 返回所有统计数据的拷贝.
+func (agg *Aggregator) Snapshot() map[Key]Counts {
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+
+	m := make(map[Key]Counts, len(agg.counts))
+	for key, counts := range agg.counts {
+		m[key] = *counts
+	}
+	return m
+}
+
+// Prune 删除 date(YYYY-MM-DD) 之前(不包括 date)的统计数据, 一般在对账完成后调用以释放内存.
+func (agg *Aggregator) Prune(date string) {
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+
+	for key := range agg.counts {
+		if key.Date < date {
+			delete(agg.counts, key)
+		}
+	}
+	for d := range agg.seen {
+		if d < date {
+			delete(agg.seen, d)
+		}
+	}
+}
+
+// Diff 是一张卡券(CardId 为空表示所有卡券的汇总)某一天事件统计和数据统计接口不一致的地方.
+type Diff struct {
+	CardId string
+	Date   string
+
+	EventGet        int // user_get_card 事件数量
+	DatacubeReceive int // 数据统计接口的领取次数
+	EventConsume    int // user_consume_card 事件数量
+	DatacubeVerify  int // 数据统计接口的使用次数
+}
+
+// MissedGet 返回可能丢失的 user_get_card 事件数量, 负数表示事件比数据统计接口多.
+func (diff *Diff) MissedGet() int {
+	return diff.DatacubeReceive - diff.EventGet
+}
+
+// MissedConsume 返回可能丢失的 user_consume_card 事件数量, 负数表示事件比数据统计接口多.
+func (diff *Diff) MissedConsume() int {
+	return diff.DatacubeVerify - diff.EventConsume
+}
+
+// Reconcile 按照卡券和日期对比事件统计和 datacube/card.GetCardInfo 返回的数据, 返回不一致的记录(按日期和卡券ID排序).
+//
+//	NOTE: 只对比 list 中出现的卡券和日期; list 中没有但是有事件的卡券, 可能是数据统计接口不支持的卡券类型(比如会员卡), 不做对比.
+func Reconcile(agg *Aggregator, list []datacube.CardData) []Diff {
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+
+	var diffs []Diff
+	for i := range list {
+		data := &list[i]
+		var counts Counts
+		if p := agg.counts[Key{CardId: data.CardId, Date: data.RefDate.String()}]; p != nil {
+			counts = *p
+		}
+		if counts.Get == data.ReceiveCount && counts.Consume == data.VerifyCount {
+			continue
+		}
+		diffs = append(diffs, Diff{
+			CardId:          data.CardId,
+			Date:            data.RefDate.String(),
+			EventGet:        counts.Get,
+			DatacubeReceive: data.ReceiveCount,
+			EventConsume:    counts.Consume,
+			DatacubeVerify:  data.VerifyCount,
+		})
+	}
+	sortDiffs(diffs)
+	return diffs
+}
+
+// ReconcileTotal 按照日期对比所有卡券的事件统计汇总和 datacube/card.GetBizUinInfo 返回的数据, 返回不一致的记录(Diff.CardId 为空).
+func ReconcileTotal(agg *Aggregator, list []datacube.BizUinData) []Diff {
+	agg.mu.Lock()
+	totals := make(map[string]Counts)
+	for key, counts := range agg.counts {
+		total := totals[key.Date]
+		total.Get += counts.Get
+		total.Consume += counts.Consume
+		total.Del += counts.Del
+		totals[key.Date] = total
+	}
+	agg.mu.Unlock()
+
+	var diffs []Diff
+	for i := range list {
+		data := &list[i]
+		counts := totals[data.RefDate.String()]
+		if counts.Get == data.ReceiveCount && counts.Consume == data.VerifyCount {
+			continue
+		}
+		diffs = append(diffs, Diff{
+			Date:            data.RefDate.String(),
+			EventGet:        counts.Get,
+			DatacubeReceive: data.ReceiveCount,
+			EventConsume:    counts.Consume,
+			DatacubeVerify:  data.VerifyCount,
+		})
+	}
+	sortDiffs(diffs)
+	return diffs
+}
+
+// Fetch 调用数据统计接口拉取 req 指定日期范围内的数据, 然后和事件统计对账, 返回每张卡券的和汇总的不一致记录.
+func Fetch(clt *core.Client, agg *Aggregator, req *datacube.Request) (cardDiffs, totalDiffs []Diff, err error) {
+	cardList, err := datacube.GetCardInfo(clt, req)
+	if err != nil {
+		return
+	}
+	if req.CardId != "" {
+		cardDiffs = Reconcile(agg, cardList)
+		return
+	}
+	bizUinList, err := datacube.GetBizUinInfo(clt, req)
+	if err != nil {
+		return
+	}
+	cardDiffs = Reconcile(agg, cardList)
+	totalDiffs = ReconcileTotal(agg, bizUinList)
+	return
+}
+
+func sortDiffs(diffs []Diff) {
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Date != diffs[j].Date {
+			return diffs[i].Date < diffs[j].Date
+		}
+		return diffs[i].CardId < diffs[j].CardId
+	})
+}
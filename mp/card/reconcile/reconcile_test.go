@@ -0,0 +1,64 @@
+package reconcile
+
+import (
+	"testing"
+
+	"github.com/chanxuehong/wechat/mp/core"
+	mpdatacube "github.com/chanxuehong/wechat/mp/datacube"
+	datacube "github.com/chanxuehong/wechat/mp/datacube/card"
+)
+
+func newEvent(eventType core.EventType, cardId, code string, createTime int64) eventId {
+	return eventId{fromUserName: "from", createTime: createTime, eventType: eventType, cardId: cardId, userCardCode: code}
+}
+
+func TestReconcile(t *testing.T) {
+	agg := NewAggregator()
+
+	// 2020-01-02 00:00:00 +0800
+	if !agg.add(newEvent("user_get_card", "card1", "001", 1577894400)) {
+		t.Fatal("want true")
+	}
+	if agg.add(newEvent("user_get_card", "card1", "001", 1577894400)) {
+		t.Fatal("duplicate event, want false")
+	}
+	agg.add(newEvent("user_get_card", "card1", "002", 1577894401))
+	agg.add(newEvent("user_consume_card", "card1", "001", 1577894402))
+	agg.add(newEvent("user_del_card", "card1", "002", 1577894403))
+	// 2020-01-01 23:59:59 +0800
+	agg.add(newEvent("user_get_card", "card2", "003", 1577894399))
+
+	if have, want := agg.Counts("card1", "2020-01-02"), (Counts{Get: 2, Consume: 1, Del: 1}); have != want {
+		t.Errorf("have %+v, want %+v", have, want)
+	}
+	if have, want := agg.Counts("card2", "2020-01-01"), (Counts{Get: 1}); have != want {
+		t.Errorf("have %+v, want %+v", have, want)
+	}
+
+	diffs := Reconcile(agg, []datacube.CardData{
+		{RefDate: mpdatacube.NewDate(2020, 1, 2), CardId: "card1", ReceiveCount: 3, VerifyCount: 1},
+		{RefDate: mpdatacube.NewDate(2020, 1, 1), CardId: "card2", ReceiveCount: 1},
+	})
+	if len(diffs) != 1 {
+		t.Fatalf("have %d diffs, want 1", len(diffs))
+	}
+	if diffs[0].CardId != "card1" || diffs[0].MissedGet() != 1 || diffs[0].MissedConsume() != 0 {
+		t.Errorf("unexpected diff: %+v", diffs[0])
+	}
+
+	totals := ReconcileTotal(agg, []datacube.BizUinData{
+		{RefDate: mpdatacube.NewDate(2020, 1, 1), ReceiveCount: 1},
+		{RefDate: mpdatacube.NewDate(2020, 1, 2), ReceiveCount: 2, VerifyCount: 2},
+	})
+	if len(totals) != 1 || totals[0].Date != "2020-01-02" || totals[0].MissedConsume() != 1 {
+		t.Errorf("unexpected total diffs: %+v", totals)
+	}
+
+	agg.Prune("2020-01-02")
+	if have := agg.Counts("card2", "2020-01-01"); have != (Counts{}) {
+		t.Errorf("have %+v after Prune", have)
+	}
+	if len(agg.Snapshot()) != 1 {
+		t.Errorf("have %d keys after Prune, want 1", len(agg.Snapshot()))
+	}
+}
@@ -0,0 +1,68 @@
+package user
+
+import (
+	"github.com/chanxuehong/wechat/mp/card/code"
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+// CardIterator 遍历用户已领取的卡券.
+//
+//	NOTE: 获取用户已领取卡券接口没有分页, 一次返回全部的卡券, CardIterator 在本地按照 pageSize 分页,
+//	只是为了和 card.CardIterator 等遍历器保持一致的用法.
+//
+//	iter, err := NewCardIterator(clt, "OpenId", "", 50)
+//	if err != nil {
+//	    // TODO: 增加你的代码
+//	}
+//
+//	for iter.HasNext() {
+//	    items, err := iter.NextPage()
+//	    if err != nil {
+//	        // TODO: 增加你的代码
+//	    }
+//	    // TODO: 增加你的代码
+//	}
+type CardIterator struct {
+	list       []code.CardItemIdentifier
+	nextOffset int
+	pageSize   int
+}
+
+func (iter *CardIterator) TotalCount() int {
+	return len(iter.list)
+}
+
+func (iter *CardIterator) HasNext() bool {
+	return iter.nextOffset < len(iter.list)
+}
+
+func (iter *CardIterator) NextPage() (list []code.CardItemIdentifier, err error) {
+	end := iter.nextOffset + iter.pageSize
+	if end > len(iter.list) {
+		end = len(iter.list)
+	}
+	list = iter.list[iter.nextOffset:end]
+	iter.nextOffset = end
+	return
+}
+
+// NewCardIterator 获取用户已领取卡券的遍历器.
+//
+//	openid:   需要查询的用户openid
+//	cardid:   卡券ID。不填写时默认查询当前appid下的卡券。
+//	pageSize: 每页的数量, 如果 <= 0 则一页返回全部的卡券
+func NewCardIterator(clt *core.Client, openid, cardid string, pageSize int) (iter *CardIterator, err error) {
+	list, err := GetCardList(clt, openid, cardid)
+	if err != nil {
+		return
+	}
+	if pageSize <= 0 {
+		pageSize = len(list)
+	}
+
+	iter = &CardIterator{
+		list:     list,
+		pageSize: pageSize,
+	}
+	return
+}
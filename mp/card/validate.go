@@ -0,0 +1,341 @@
+package card
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+type validator struct {
+	colors   []Color // 合法的颜色, 为空则使用 Colors
+	problems []string
+}
+
+func (v *validator) addf(field, format string, args ...interface{}) {
+	v.problems = append(v.problems, field+": "+fmt.Sprintf(format, args...))
+}
+
+// checkWidth 检查 s 的长度不超过 n 个汉字, 一个汉字(非 ASCII 字符)算 2, 一个 ASCII 字符算 1.
+func (v *validator) checkWidth(field, s string, n int, required bool) {
+	if s == "" {
+		if required {
+			v.addf(field, "required")
+		}
+		return
+	}
+	width := 0
+	for _, r := range s {
+		if r < 0x80 {
+			width++
+		} else {
+			width += 2
+		}
+	}
+	if width > n*2 {
+		v.addf(field, "too long, max %d Chinese characters (%d ASCII characters)", n, n*2)
+	}
+}
+
+func (v *validator) checkURL(field, s string, required bool) {
+	if s == "" {
+		if required {
+			v.addf(field, "required")
+		}
+		return
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addf(field, "invalid url %q", s)
+	}
+}
+
+// Validate 在调用 Create 之前检查卡券数据是否满足文档描述的规则, 并一次性返回所有的问题(*core.ValidationError).
+//
+//	NOTE: Validate 只检查创建卡券时的规则, 不检查 Update 时的规则(Update 只需要填写要修改的字段).
+func (card *Card) Validate() error {
+	return card.ValidateWithColors(nil)
+}
+
+// ValidateWithColors 同 Validate, 但是 color 必须是 colors 中的颜色, 一般是 GetColors 的结果; colors 为空则同 Validate.
+func (card *Card) ValidateWithColors(colors []Color) error {
+	v := &validator{colors: colors}
+
+	type cardPart struct {
+		cardType string
+		field    string
+		set      bool
+	}
+	parts := []cardPart{
+		{CardTypeGeneralCoupon, "general_coupon", card.GeneralCoupon != nil},
+		{CardTypeGroupon, "groupon", card.Groupon != nil},
+		{CardTypeCash, "cash", card.Cash != nil},
+		{CardTypeDiscount, "discount", card.Discount != nil},
+		{CardTypeGift, "gift", card.Gift != nil},
+		{CardTypeMemberCard, "member_card", card.MemberCard != nil},
+		{CardTypeMeetingTicket, "meeting_ticket", card.MeetingTicket != nil},
+		{CardTypeScenicTicket, "scenic_ticket", card.ScenicTicket != nil},
+		{CardTypeMovieTicket, "movie_ticket", card.MovieTicket != nil},
+		{CardTypeBoardingPass, "boarding_pass", card.BoardingPass != nil},
+	}
+	knownType := false
+	var setFields []string
+	for _, part := range parts {
+		if part.cardType == card.CardType {
+			knownType = true
+			if !part.set {
+				v.addf(part.field, "required for card_type %s", card.CardType)
+			}
+		}
+		if part.set {
+			setFields = append(setFields, part.field)
+			if part.cardType != card.CardType {
+				v.addf(part.field, "does not match card_type %q", card.CardType)
+			}
+		}
+	}
+	if !knownType {
+		v.addf("card_type", "unknown card type %q", card.CardType)
+	}
+	if len(setFields) > 1 {
+		v.addf("card", "exactly one card-type object allowed, have %s", strings.Join(setFields, ", "))
+	}
+
+	switch card.CardType {
+	case CardTypeGeneralCoupon:
+		if p := card.GeneralCoupon; p != nil {
+			v.checkBaseInfo("general_coupon.base_info", p.BaseInfo, card.CardType)
+			if p.DefaultDetail == "" {
+				v.addf("general_coupon.default_detail", "required")
+			}
+		}
+	case CardTypeGroupon:
+		if p := card.Groupon; p != nil {
+			v.checkBaseInfo("groupon.base_info", p.BaseInfo, card.CardType)
+			if p.DealDetail == "" {
+				v.addf("groupon.deal_detail", "required")
+			}
+		}
+	case CardTypeCash:
+		if p := card.Cash; p != nil {
+			v.checkBaseInfo("cash.base_info", p.BaseInfo, card.CardType)
+			v.checkCash(p)
+		}
+	case CardTypeDiscount:
+		if p := card.Discount; p != nil {
+			v.checkBaseInfo("discount.base_info", p.BaseInfo, card.CardType)
+			switch {
+			case p.Discount == nil:
+				v.addf("discount.discount", "required")
+			case *p.Discount <= 0 || *p.Discount >= 100:
+				v.addf("discount.discount", "must be in range [1, 99], have %d", *p.Discount)
+			}
+		}
+	case CardTypeGift:
+		if p := card.Gift; p != nil {
+			v.checkBaseInfo("gift.base_info", p.BaseInfo, card.CardType)
+			if p.Gift == "" {
+				v.addf("gift.gift", "required")
+			}
+		}
+	case CardTypeMemberCard:
+		if p := card.MemberCard; p != nil {
+			v.checkBaseInfo("member_card.base_info", p.BaseInfo, card.CardType)
+			v.checkMemberCard(p)
+		}
+	case CardTypeMeetingTicket:
+		if p := card.MeetingTicket; p != nil {
+			v.checkBaseInfo("meeting_ticket.base_info", p.BaseInfo, card.CardType)
+			v.checkURL("meeting_ticket.map_url", p.MapURL, false)
+		}
+	case CardTypeScenicTicket:
+		if p := card.ScenicTicket; p != nil {
+			v.checkBaseInfo("scenic_ticket.base_info", p.BaseInfo, card.CardType)
+			v.checkURL("scenic_ticket.guide_url", p.GuideURL, false)
+		}
+	case CardTypeMovieTicket:
+		if p := card.MovieTicket; p != nil {
+			v.checkBaseInfo("movie_ticket.base_info", p.BaseInfo, card.CardType)
+		}
+	case CardTypeBoardingPass:
+		if p := card.BoardingPass; p != nil {
+			v.checkBaseInfo("boarding_pass.base_info", p.BaseInfo, card.CardType)
+			v.checkWidth("boarding_pass.from", p.From, 18, true)
+			v.checkWidth("boarding_pass.to", p.To, 18, true)
+			v.checkWidth("boarding_pass.air_model", p.AirModel, 8, false)
+			if p.Flight == "" {
+				v.addf("boarding_pass.flight", "required")
+			}
+			if p.DepartureTime > 0 && p.LandingTime > 0 && p.LandingTime <= p.DepartureTime {
+				v.addf("boarding_pass.landing_time", "must be after departure_time")
+			}
+			v.checkURL("boarding_pass.check_in_url", p.CheckinURL, false)
+		}
+	}
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &core.ValidationError{Subject: "card", Problems: v.problems}
+}
+
+// Colors 是色彩规范中的颜色, 微信可能会调整, 最新的颜色列表可以调用 GetColors 获取.
+var Colors = []Color{
+	{Name: "Color010", Value: "#63b359"},
+	{Name: "Color020", Value: "#2c9f67"},
+	{Name: "Color030", Value: "#509fc9"},
+	{Name: "Color040", Value: "#5885cf"},
+	{Name: "Color050", Value: "#9062c0"},
+	{Name: "Color060", Value: "#d09a45"},
+	{Name: "Color070", Value: "#e4b138"},
+	{Name: "Color080", Value: "#ee903c"},
+	{Name: "Color081", Value: "#f08500"},
+	{Name: "Color082", Value: "#a9d92d"},
+	{Name: "Color090", Value: "#dd6549"},
+	{Name: "Color100", Value: "#cc463d"},
+}
+
+// ValidColor 判断 color 是否是 colors 中的颜色名称, 如果没有指定 colors 则使用 Colors.
+//
+//	colors 一般是 GetColors 的结果.
+func ValidColor(color string, colors ...Color) bool {
+	if len(colors) == 0 {
+		colors = Colors
+	}
+	for i := range colors {
+		if colors[i].Name == color {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validator) checkBaseInfo(field string, info *CardBaseInfo, cardType string) {
+	if info == nil {
+		v.addf(field, "required")
+		return
+	}
+	v.checkURL(field+".logo_url", info.LogoURL, true)
+	switch info.CodeType {
+	case CodeTypeText, CodeTypeBarCode, CodeTypeQrcode, CodeTypeOnlyBarCode, CodeTypeOnlyQrcode:
+	case "":
+		v.addf(field+".code_type", "required")
+	default:
+		v.addf(field+".code_type", "unknown code type %q", info.CodeType)
+	}
+	v.checkWidth(field+".brand_name", info.BrandName, 12, true)
+	v.checkWidth(field+".title", info.Title, 9, true)
+	v.checkWidth(field+".sub_title", info.SubTitle, 18, false)
+	switch {
+	case info.Color == "":
+		v.addf(field+".color", "required")
+	case !ValidColor(info.Color, v.colors...):
+		v.addf(field+".color", "must be a color returned by GetColors, have %q", info.Color)
+	}
+	v.checkWidth(field+".notice", info.Notice, 16, true)
+	v.checkWidth(field+".description", info.Description, 1024, true)
+
+	switch {
+	case info.SKU == nil:
+		v.addf(field+".sku", "required")
+	case info.SKU.Quantity <= 0 || info.SKU.Quantity > 100000000:
+		v.addf(field+".sku.quantity", "must be in range [1, 100000000], have %d", info.SKU.Quantity)
+	}
+
+	v.checkDateInfo(field+".date_info", info.DateInfo, cardType)
+
+	if info.GetLimit != nil && *info.GetLimit <= 0 {
+		v.addf(field+".get_limit", "must be positive, have %d", *info.GetLimit)
+	}
+	if info.UseLimit != nil && *info.UseLimit <= 0 {
+		v.addf(field+".use_limit", "must be positive, have %d", *info.UseLimit)
+	}
+	v.checkURL(field+".custom_url", info.CustomURL, info.CustomURLName != "")
+	v.checkURL(field+".promotion_url", info.PromotionURL, info.PromotionURLName != "")
+}
+
+func (v *validator) checkDateInfo(field string, info *DateInfo, cardType string) {
+	if info == nil {
+		v.addf(field, "required")
+		return
+	}
+	switch info.Type {
+	case DateInfoTypeFixTimeRange:
+		if info.BeginTimestamp <= 0 {
+			v.addf(field+".begin_timestamp", "required for %s", info.Type)
+		}
+		if info.EndTimestamp <= 0 {
+			v.addf(field+".end_timestamp", "required for %s", info.Type)
+		} else if info.EndTimestamp <= info.BeginTimestamp {
+			v.addf(field+".end_timestamp", "must be after begin_timestamp")
+		}
+		if info.FixedTerm != nil || info.FixedBeginTerm != nil {
+			v.addf(field, "fixed_term and fixed_begin_term not allowed for %s", info.Type)
+		}
+	case DateInfoTypeFixTerm:
+		switch {
+		case info.FixedTerm == nil:
+			v.addf(field+".fixed_term", "required for %s", info.Type)
+		case *info.FixedTerm < 0:
+			v.addf(field+".fixed_term", "must not be negative, have %d", *info.FixedTerm)
+		}
+		if info.FixedBeginTerm != nil && *info.FixedBeginTerm < 0 {
+			v.addf(field+".fixed_begin_term", "must not be negative, have %d", *info.FixedBeginTerm)
+		}
+		if info.BeginTimestamp != 0 {
+			v.addf(field+".begin_timestamp", "not allowed for %s", info.Type)
+		}
+	case DateInfoTypePermanent:
+		if cardType != CardTypeMemberCard {
+			v.addf(field+".type", "%s only allowed for %s", info.Type, CardTypeMemberCard)
+		}
+	case "":
+		v.addf(field+".type", "required")
+	default:
+		v.addf(field+".type", "unknown date type %q", info.Type)
+	}
+}
+
+func (v *validator) checkCash(cash *Cash) {
+	if cash.ReduceCost == nil {
+		v.addf("cash.reduce_cost", "required")
+	} else if *cash.ReduceCost <= 0 {
+		v.addf("cash.reduce_cost", "must be positive, have %d", *cash.ReduceCost)
+	}
+	if cash.LeastCost == nil {
+		v.addf("cash.least_cost", "required")
+	} else if *cash.LeastCost < 0 {
+		v.addf("cash.least_cost", "must not be negative, have %d", *cash.LeastCost)
+	}
+	if cash.LeastCost != nil && cash.ReduceCost != nil && *cash.LeastCost > 0 && *cash.LeastCost < *cash.ReduceCost {
+		v.addf("cash.least_cost", "must not be less than reduce_cost (%d < %d)", *cash.LeastCost, *cash.ReduceCost)
+	}
+	if cash.AdvancedInfo != nil && cash.AdvancedInfo.UseCondition != nil && cash.LeastCost != nil {
+		if leastCost := cash.AdvancedInfo.UseCondition.LeastCost; leastCost != 0 && leastCost != *cash.LeastCost {
+			v.addf("cash.advanced_info.use_condition.least_cost", "conflicts with cash.least_cost (%d != %d)", leastCost, *cash.LeastCost)
+		}
+	}
+}
+
+func (v *validator) checkMemberCard(card *MemberCard) {
+	if card.Prerogative == "" {
+		v.addf("member_card.prerogative", "required")
+	}
+	if card.SupplyBonus == nil {
+		v.addf("member_card.supply_bonus", "required")
+	}
+	if card.SupplyBalance == nil {
+		v.addf("member_card.supply_balance", "required")
+	}
+	v.checkURL("member_card.bonus_url", card.BonusURL, false)
+	v.checkURL("member_card.balance_url", card.BalanceURL, false)
+	v.checkURL("member_card.activate_url", card.ActivateURL, false)
+	if cell := card.CustomCell1; cell != nil {
+		if cell.Name == "" {
+			v.addf("member_card.custom_cell1.name", "required")
+		}
+		v.checkWidth("member_card.custom_cell1.tips", cell.Tips, 6, false)
+		v.checkURL("member_card.custom_cell1.url", cell.URL, true)
+	}
+}
@@ -0,0 +1,88 @@
+package card
+
+import (
+	"testing"
+
+	"github.com/chanxuehong/wechat/mp/core"
+	"github.com/chanxuehong/wechat/util"
+)
+
+func newTestCashCard() *Card {
+	return &Card{
+		CardType: CardTypeCash,
+		Cash: &Cash{
+			BaseInfo: &CardBaseInfo{
+				LogoURL:     "http://mmbiz.qpic.cn/mmbiz/iaL1LJM1mF9aRKPZ/0",
+				CodeType:    CodeTypeText,
+				BrandName:   "微信餐厅",
+				Title:       "132元双人火锅套餐",
+				Color:       "Color010",
+				Notice:      "使用时向服务员出示此券",
+				Description: "不可与其他优惠同享",
+				SKU:         &SKU{Quantity: 500000},
+				DateInfo: &DateInfo{
+					Type:           DateInfoTypeFixTimeRange,
+					BeginTimestamp: 1397577600,
+					EndTimestamp:   1472724261,
+				},
+			},
+			LeastCost:  util.Int(10000),
+			ReduceCost: util.Int(1000),
+		},
+	}
+}
+
+func TestCardValidate(t *testing.T) {
+	if err := newTestCashCard().Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	card := newTestCashCard()
+	card.Cash.BaseInfo.Title = "这是一个非常非常长的卡券名称"
+	card.Cash.BaseInfo.Color = "Color200"
+	card.Cash.BaseInfo.SKU.Quantity = 0
+	card.Cash.BaseInfo.DateInfo = &DateInfo{Type: DateInfoTypePermanent}
+	card.Cash.LeastCost = util.Int(100)
+	card.Gift = &Gift{}
+
+	err := card.Validate()
+	verr, ok := err.(*core.ValidationError)
+	if !ok {
+		t.Fatalf("have %v, want *core.ValidationError", err)
+	}
+	// title, color, sku.quantity, date_info.type, least_cost, gift does not match, exactly one
+	if len(verr.Problems) != 7 {
+		t.Errorf("have %d problems, want 7: %v", len(verr.Problems), verr)
+	}
+}
+
+func TestValidColor(t *testing.T) {
+	for color, want := range map[string]bool{
+		"Color010": true,
+		"Color100": true,
+		"Color082": true,
+		"Color009": false,
+		"Color011": false,
+		"Color101": false,
+		"color010": false,
+		"Color10":  false,
+	} {
+		if have := ValidColor(color); have != want {
+			t.Errorf("ValidColor(%q): have %v, want %v", color, have, want)
+		}
+	}
+
+	// GetColors 返回的颜色列表
+	colors := []Color{{Name: "Color101", Value: "#cf3e36"}}
+	if !ValidColor("Color101", colors...) || ValidColor("Color010", colors...) {
+		t.Error("ValidColor with colors")
+	}
+	card := newTestCashCard()
+	card.Cash.BaseInfo.Color = "Color101"
+	if err := card.Validate(); err == nil {
+		t.Error("want error for Color101")
+	}
+	if err := card.ValidateWithColors(colors); err != nil {
+		t.Errorf("ValidateWithColors: %v", err)
+	}
+}
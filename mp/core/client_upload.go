@@ -7,6 +7,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"os"
 
 	"github.com/chanxuehong/wechat/internal/debug/api"
 	"github.com/chanxuehong/wechat/internal/debug/api/retry"
@@ -18,6 +19,11 @@ type MultipartFormField struct {
 	Name     string
 	FileName string
 	Value    io.Reader
+
+	// Open 可选, 设置了 Open 时调用 Open 获取字段的内容(忽略 Value), 读取结束后关闭;
+	// 流式上传的时候每次发送请求都会调用, 用于不能 Seek 的内容在 access_token 失效重试时重新读取,
+	// 比如重新打开文件, 重新下载网络资源.
+	Open func() (io.ReadCloser, error)
 }
 
 // PostMultipartForm 通用上传接口.
@@ -37,12 +43,19 @@ type MultipartFormField struct {
 //	NOTE:
 //	1. 一般不需要调用这个方法, 请直接调用高层次的封装函数;
 //	2. 最终的 URL == incompleteURL + access_token;
-//	3. response 格式有要求, 要么是 *Error, 要么是下面结构体的指针(注意 Error 必须是第一个 Field):
+//	3. 如果有字段是普通文件(*os.File)或者设置了 Open, 并且每个字段都可以重新读取(其他字段是可以 Seek 的 io.Seeker),
+//	   通过 PostMultipartFormStream 流式上传, 否则把整个请求体读入内存再上传(调试时会输出请求体);
+//	   管道, FIFO, 标准输入之类的 *os.File 虽然实现了 io.Seeker 但是 Seek 会失败, 这时候也读入内存;
+//	4. response 格式有要求, 要么是 *Error, 要么是下面结构体的指针(注意 Error 必须是第一个 Field):
 //	    struct {
 //	        Error
 //	        ...
 //	    }
 func (clt *Client) PostMultipartForm(incompleteURL string, fields []MultipartFormField, response interface{}) (err error) {
+	if canStream(fields) {
+		return clt.PostMultipartFormStream(incompleteURL, fields, response)
+	}
+
 	ErrorStructValue, ErrorErrCodeValue := checkResponse(response)
 
 	buffer := mediaBufferPool.Get().(*bytes.Buffer)
@@ -50,26 +63,20 @@ func (clt *Client) PostMultipartForm(incompleteURL string, fields []MultipartFor
 	defer mediaBufferPool.Put(buffer)
 
 	multipartWriter := multipart.NewWriter(buffer)
+	values := make([]io.Reader, len(fields))
 	for i := 0; i < len(fields); i++ {
-		if field := &fields[i]; field.IsFile {
-			partWriter, err3 := multipartWriter.CreateFormFile(field.Name, field.FileName)
-			if err3 != nil {
-				return err3
-			}
-			if _, err3 = io.Copy(partWriter, field.Value); err3 != nil {
-				return err3
-			}
-		} else {
-			partWriter, err3 := multipartWriter.CreateFormField(field.Name)
-			if err3 != nil {
-				return err3
-			}
-			if _, err3 = io.Copy(partWriter, field.Value); err3 != nil {
-				return err3
-			}
+		if fields[i].Open == nil {
+			values[i] = fields[i].Value
+			continue
 		}
+		rc, err := fields[i].Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		values[i] = rc
 	}
-	if err = multipartWriter.Close(); err != nil {
+	if err = writeMultipartForm(multipartWriter, fields, values); err != nil {
 		return
 	}
 	requestBodyBytes := buffer.Bytes()
@@ -114,6 +121,31 @@ RETRY:
 	}
 }
 
+// canStream 判断 fields 是否需要流式上传.
+// 只有包含文件(*os.File 或者设置了 Open 的字段)的时候才流式上传, 并且在重试的时候每个字段的内容都要可以重新读取;
+// *bytes.Reader, *strings.Reader 之类的内存数据读入内存上传, 这样调试的时候可以输出请求体.
+// 只看类型是不够的, 比如管道和标准输入对应的 *os.File 实现了 io.Seeker, 但是 Seek 返回 "illegal seek".
+func canStream(fields []MultipartFormField) bool {
+	hasFile := false
+	for i := 0; i < len(fields); i++ {
+		if fields[i].Open != nil {
+			hasFile = true
+			continue
+		}
+		seeker, ok := fields[i].Value.(io.Seeker)
+		if !ok {
+			return false
+		}
+		if _, err := seeker.Seek(0, io.SeekCurrent); err != nil {
+			return false
+		}
+		if _, ok := fields[i].Value.(*os.File); ok {
+			hasFile = true
+		}
+	}
+	return hasFile
+}
+
 func httpPostMultipartForm(clt *http.Client, url, bodyType string, body []byte, response interface{}) error {
 	api.DebugPrintPostMultipartRequest(url, body)
 	httpResp, err := clt.Post(url, bodyType, bytes.NewReader(body))
@@ -127,3 +159,24 @@ func httpPostMultipartForm(clt *http.Client, url, bodyType string, body []byte,
 	}
 	return api.DecodeJSONHttpResponse(httpResp.Body, response)
 }
+
+// writeMultipartForm 把 fields 写入 multipartWriter 并关闭 multipartWriter, values[i] 是 fields[i] 的内容.
+func writeMultipartForm(multipartWriter *multipart.Writer, fields []MultipartFormField, values []io.Reader) error {
+	for i := 0; i < len(fields); i++ {
+		partWriter, err := createPart(multipartWriter, &fields[i])
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(partWriter, values[i]); err != nil {
+			return err
+		}
+	}
+	return multipartWriter.Close()
+}
+
+func createPart(multipartWriter *multipart.Writer, field *MultipartFormField) (io.Writer, error) {
+	if field.IsFile {
+		return multipartWriter.CreateFormFile(field.Name, field.FileName)
+	}
+	return multipartWriter.CreateFormField(field.Name)
+}
@@ -0,0 +1,196 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+
+	"github.com/chanxuehong/wechat/internal/debug/api"
+	"github.com/chanxuehong/wechat/internal/debug/api/retry"
+	"github.com/chanxuehong/wechat/util"
+)
+
+// PostMultipartFormStream 和 PostMultipartForm 一样是通用上传接口,
+// 不同的是请求体通过 io.Pipe 边读边发送, 不会把整个文件读入内存, 适合上传大文件.
+//
+//	NOTE:
+//	1. 一般不需要调用这个方法, 请直接调用高层次的封装函数, PostMultipartForm 在可以流式上传的时候会自动调用这个方法;
+//	2. 为了在 access_token 失效时重试, 每个 field 要么设置了 Open, 要么 Value 实现了 io.Seeker(比如 *os.File),
+//	   否则返回错误, 这种情况请使用 PostMultipartForm;
+//	3. 如果所有字段内容的长度都可以确定(Value 实现了 io.Seeker 或者 Len() int 方法), 会设置请求的 Content-Length,
+//	   否则使用 chunked 编码发送;
+//	4. response 格式的要求同 PostMultipartForm.
+func (clt *Client) PostMultipartFormStream(incompleteURL string, fields []MultipartFormField, response interface{}) (err error) {
+	ErrorStructValue, ErrorErrCodeValue := checkResponse(response)
+
+	// 记录 Value 的初始位置, 重试的时候 Seek 到初始位置
+	offsets := make([]int64, len(fields))
+	for i := 0; i < len(fields); i++ {
+		field := &fields[i]
+		if field.Open != nil {
+			continue
+		}
+		seeker, ok := field.Value.(io.Seeker)
+		if !ok {
+			return fmt.Errorf("multipart field %q: Value must implement io.Seeker or Open must be set", field.Name)
+		}
+		if offsets[i], err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			return
+		}
+	}
+
+	httpClient := clt.HttpClient
+	if httpClient == nil {
+		httpClient = util.DefaultMediaHttpClient
+	}
+
+	token, err := clt.Token()
+	if err != nil {
+		return
+	}
+
+	hasRetried := false
+RETRY:
+	finalURL := incompleteURL + url.QueryEscape(token)
+	if err = httpPostMultipartFormStream(httpClient, finalURL, fields, response); err != nil {
+		return
+	}
+
+	switch errCode := ErrorErrCodeValue.Int(); errCode {
+	case ErrCodeOK:
+		return
+	case ErrCodeInvalidCredential, ErrCodeAccessTokenExpired:
+		errMsg := ErrorStructValue.Field(errorErrMsgIndex).String()
+		retry.DebugPrintError(errCode, errMsg, token)
+		if !hasRetried {
+			hasRetried = true
+			ErrorStructValue.Set(errorZeroValue)
+			for i := 0; i < len(fields); i++ {
+				if fields[i].Open != nil {
+					continue
+				}
+				if _, err = fields[i].Value.(io.Seeker).Seek(offsets[i], io.SeekStart); err != nil {
+					return
+				}
+			}
+			if token, err = clt.RefreshToken(token); err != nil {
+				return
+			}
+			retry.DebugPrintNewToken(token)
+			goto RETRY
+		}
+		retry.DebugPrintFallthrough(token)
+		fallthrough
+	default:
+		return
+	}
+}
+
+func httpPostMultipartFormStream(clt *http.Client, url string, fields []MultipartFormField, response interface{}) error {
+	values := make([]io.Reader, len(fields))
+	for i := 0; i < len(fields); i++ {
+		if fields[i].Open == nil {
+			values[i] = fields[i].Value
+			continue
+		}
+		rc, err := fields[i].Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		values[i] = rc
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	multipartWriter := multipart.NewWriter(pipeWriter)
+	contentLength := multipartContentLength(multipartWriter.Boundary(), fields, values)
+
+	writeDone := make(chan error, 1)
+	go func() {
+		err := writeMultipartForm(multipartWriter, fields, values)
+		pipeWriter.CloseWithError(err)
+		writeDone <- err
+	}()
+
+	httpReq, err := http.NewRequest(http.MethodPost, url, pipeReader)
+	if err != nil {
+		pipeReader.Close()
+		<-writeDone
+		return err
+	}
+	httpReq.Header.Set("Content-Type", multipartWriter.FormDataContentType())
+	httpReq.ContentLength = contentLength
+
+	api.DebugPrintPostMultipartRequest(url, nil)
+	httpResp, err := clt.Do(httpReq)
+	pipeReader.Close() // 服务器没有读完请求体就返回的时候结束写入
+	writeErr := <-writeDone
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http.Status: %s", httpResp.Status)
+	}
+	if writeErr != nil && writeErr != io.ErrClosedPipe {
+		return writeErr
+	}
+	return api.DecodeJSONHttpResponse(httpResp.Body, response)
+}
+
+// multipartContentLength 返回 writeMultipartForm 写入的总长度, 不能确定返回 -1.
+func multipartContentLength(boundary string, fields []MultipartFormField, values []io.Reader) int64 {
+	var counter countWriter
+	multipartWriter := multipart.NewWriter(&counter)
+	if err := multipartWriter.SetBoundary(boundary); err != nil {
+		return -1
+	}
+	for i := 0; i < len(fields); i++ {
+		size := readerSize(values[i])
+		if size < 0 {
+			return -1
+		}
+		if _, err := createPart(multipartWriter, &fields[i]); err != nil {
+			return -1
+		}
+		counter.n += size
+	}
+	if err := multipartWriter.Close(); err != nil {
+		return -1
+	}
+	return counter.n
+}
+
+// readerSize 返回 r 剩余未读的长度, 不能确定返回 -1.
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len())
+	case io.Seeker:
+		current, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := v.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err = v.Seek(current, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - current
+	}
+	return -1
+}
+
+type countWriter struct {
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+type staticTokenServer struct{}
+
+func (staticTokenServer) IID01332E16DF5011E5A9D5A4DB30FED8E1() {}
+
+func (staticTokenServer) Token() (string, error) { return "token1", nil }
+
+func (staticTokenServer) RefreshToken(string) (string, error) { return "token2", nil }
+
+// newUploadServer 返回的服务器对 token1 返回 40001, 对 token2 返回成功, 并记录每次请求的 media 字段内容.
+func newUploadServer(t *testing.T, contents *[]string, contentLengths *[]int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*contentLengths = append(*contentLengths, r.ContentLength)
+		file, _, err := r.FormFile("media")
+		if err != nil {
+			t.Errorf("FormFile: %v", err)
+			return
+		}
+		data, _ := ioutil.ReadAll(file)
+		*contents = append(*contents, string(data)+"|"+r.FormValue("description"))
+		if r.URL.Query().Get("access_token") == "token1" {
+			fmt.Fprint(w, `{"errcode":40001,"errmsg":"invalid credential"}`)
+			return
+		}
+		fmt.Fprint(w, `{"errcode":0,"errmsg":"ok"}`)
+	}))
+}
+
+func TestPostMultipartFormStreamSeeker(t *testing.T) {
+	var contents []string
+	var contentLengths []int64
+	srv := newUploadServer(t, &contents, &contentLengths)
+	defer srv.Close()
+
+	clt := NewClient(staticTokenServer{}, srv.Client())
+	file := strings.NewReader("xxhello world")
+	file.Seek(2, io.SeekStart)
+	fields := []MultipartFormField{
+		{IsFile: true, Name: "media", FileName: "a.txt", Value: file},
+		{Name: "description", Value: bytes.NewReader([]byte(`{"title":"t"}`))},
+	}
+	var result Error
+	if err := clt.PostMultipartFormStream(srv.URL+"/upload?access_token=", fields, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.ErrCode != ErrCodeOK {
+		t.Fatalf("result = %+v", result)
+	}
+	want := `hello world|{"title":"t"}`
+	if len(contents) != 2 || contents[0] != want || contents[1] != want {
+		t.Errorf("contents = %q", contents)
+	}
+	for _, n := range contentLengths {
+		if n <= 0 {
+			t.Errorf("ContentLength = %d", n)
+		}
+	}
+}
+
+func TestPostMultipartFormStreamOpen(t *testing.T) {
+	var contents []string
+	var contentLengths []int64
+	srv := newUploadServer(t, &contents, &contentLengths)
+	defer srv.Close()
+
+	clt := NewClient(staticTokenServer{}, srv.Client())
+	opened := 0
+	fields := []MultipartFormField{{
+		IsFile:   true,
+		Name:     "media",
+		FileName: "a.txt",
+		Open: func() (io.ReadCloser, error) {
+			opened++
+			return ioutil.NopCloser(io.MultiReader(strings.NewReader("hello "), strings.NewReader("world"))), nil
+		},
+	}}
+	var result Error
+	if err := clt.PostMultipartFormStream(srv.URL+"/upload?access_token=", fields, &result); err != nil {
+		t.Fatal(err)
+	}
+	if opened != 2 || len(contents) != 2 || contents[1] != "hello world|" {
+		t.Errorf("opened = %d, contents = %q", opened, contents)
+	}
+	if contentLengths[0] != -1 { // chunked
+		t.Errorf("ContentLength = %d", contentLengths[0])
+	}
+
+	fields = []MultipartFormField{{IsFile: true, Name: "media", FileName: "a.txt", Value: io.MultiReader()}}
+	if err := clt.PostMultipartFormStream(srv.URL+"/upload?access_token=", fields, &result); err == nil {
+		t.Error("want error for non-seekable Value")
+	}
+}
+
+func TestPostMultipartFormPipe(t *testing.T) {
+	var contents []string
+	var contentLengths []int64
+	srv := newUploadServer(t, &contents, &contentLengths)
+	defer srv.Close()
+
+	// 管道实现了 io.Seeker 但是 Seek 会失败, 应该读入内存上传而不是流式上传
+	pipeReader, pipeWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pipeReader.Close()
+	go func() {
+		pipeWriter.WriteString("hello world")
+		pipeWriter.Close()
+	}()
+
+	clt := NewClient(staticTokenServer{}, srv.Client())
+	fields := []MultipartFormField{{IsFile: true, Name: "media", FileName: "a.txt", Value: pipeReader}}
+	if canStream(fields) {
+		t.Fatal("canStream(pipe) = true")
+	}
+	var result Error
+	if err := clt.PostMultipartForm(srv.URL+"/upload?access_token=", fields, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.ErrCode != ErrCodeOK || len(contents) != 2 || contents[1] != "hello world|" {
+		t.Errorf("result = %+v, contents = %q", result, contents)
+	}
+
+	// 内存数据读入内存上传, 这样调试的时候可以输出请求体
+	contents, contentLengths = nil, nil
+	fields = []MultipartFormField{{IsFile: true, Name: "media", FileName: "a.txt", Value: strings.NewReader("hello world")}}
+	if canStream(fields) {
+		t.Fatal("canStream(strings.Reader) = true")
+	}
+	if err := clt.PostMultipartForm(srv.URL+"/upload?access_token=", fields, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(contents) != 2 || contents[1] != "hello world|" {
+		t.Errorf("contents = %q", contents)
+	}
+
+	// 普通文件流式上传
+	file, err := ioutil.TempFile("", "upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	file.WriteString("hello world")
+	file.Seek(0, io.SeekStart)
+	contents, contentLengths = nil, nil
+	fields = []MultipartFormField{
+		{IsFile: true, Name: "media", FileName: "a.txt", Value: file},
+		{Name: "description", Value: strings.NewReader(`{"title":"t"}`)},
+	}
+	if !canStream(fields) {
+		t.Fatal("canStream(*os.File) = false")
+	}
+	if err := clt.PostMultipartForm(srv.URL+"/upload?access_token=", fields, &result); err != nil {
+		t.Fatal(err)
+	}
+	if want := `hello world|{"title":"t"}`; len(contents) != 2 || contents[1] != want {
+		t.Errorf("contents = %q", contents)
+	}
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 const (
@@ -29,3 +30,13 @@ type Error struct {
 func (err *Error) Error() string {
 	return fmt.Sprintf("errcode: %d, errmsg: %s", err.ErrCode, err.ErrMsg)
 }
+
+// ValidationError 是调用接口之前在本地校验参数(比如 menu.Menu.Validate, card.Card.Validate)的错误, 包含所有不满足规则的地方.
+type ValidationError struct {
+	Subject  string   // 校验的对象, 比如 "menu", "card"
+	Problems []string // 一般的格式为 "字段路径: 问题描述", 字段路径使用 JSON 字段名
+}
+
+func (err *ValidationError) Error() string {
+	return "invalid " + err.Subject + ": " + strings.Join(err.Problems, "; ")
+}
@@ -83,6 +83,21 @@ type MixedMsg struct {
 		Distance float64 `xml:"Distance" json:"Distance"`
 	} `xml:"AroundBeacons>AroundBeacon,omitempty" json:"AroundBeacons,omitempty"`
 
+	// freepublish
+	PublishEventInfo *struct {
+		PublishId     string `xml:"publish_id"     json:"publish_id"`
+		PublishStatus int    `xml:"publish_status" json:"publish_status"`
+		ArticleId     string `xml:"article_id"     json:"article_id"`
+		ArticleDetail *struct {
+			Count int `xml:"count" json:"count"`
+			Items []struct {
+				Index      int    `xml:"idx"         json:"idx"`
+				ArticleURL string `xml:"article_url" json:"article_url"`
+			} `xml:"item,omitempty" json:"item,omitempty"`
+		} `xml:"article_detail,omitempty" json:"article_detail,omitempty"`
+		FailIndexes []int `xml:"fail_idx,omitempty" json:"fail_idx,omitempty"`
+	} `xml:"PublishEventInfo,omitempty" json:"PublishEventInfo,omitempty"`
+
 	UnionId string `xml:"UnionId"              json:"UnionId"` // unionId
 }
 
@@ -0,0 +1,109 @@
+package datacube
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/chanxuehong/wechat/util"
+)
+
+const dateLayout = "2006-01-02"
+
+// Date 是北京时间的日期(时间部分为 00:00:00), JSON 格式为 "YYYY-MM-DD", 零值编码为 "".
+type Date struct {
+	time.Time
+}
+
+// NewDate 返回北京时间 year-month-day 这一天.
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{Time: time.Date(year, month, day, 0, 0, 0, 0, util.BeijingLocation)}
+}
+
+// DateOf 返回 t 在其自身 Location 下的日期, 比如 2020-01-02 23:00:00 +0900 返回 2020-01-02(北京时间).
+//
+//	如果需要 t 在北京时间下的日期, 请使用 DateOf(t.In(util.BeijingLocation)).
+func DateOf(t time.Time) Date {
+	return NewDate(t.Date())
+}
+
+// ParseDate 解析 "YYYY-MM-DD" 格式的日期.
+func ParseDate(s string) (Date, error) {
+	t, err := time.ParseInLocation(dateLayout, s, util.BeijingLocation)
+	if err != nil {
+		return Date{}, err
+	}
+	return Date{Time: t}, nil
+}
+
+// AddDays 返回 d 之后 n 天(n 为负数则为之前)的日期.
+func (d Date) AddDays(n int) Date {
+	return Date{Time: d.Time.AddDate(0, 0, n)}
+}
+
+// String 返回 "YYYY-MM-DD" 格式的日期, 零值返回 "".
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(dateLayout)
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Date) UnmarshalText(text []byte) (err error) {
+	if len(text) == 0 {
+		*d = Date{}
+		return
+	}
+	*d, err = ParseDate(string(text))
+	return
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	s, err := strconv.Unquote(string(data))
+	if err != nil {
+		return errors.New("invalid date: " + string(data))
+	}
+	return d.UnmarshalText([]byte(s))
+}
+
+// Hour 是一天中的小时, 取值范围为 [0, 23].
+//
+//	数据统计接口中的 ref_hour 的取值为 000 到 2300, 分别代表的是 [000,100) 到 [2300,2400),
+//	Hour 在 JSON 编解码的时候会自动转换, 比如 1300 解码为 Hour(13).
+type Hour int
+
+// Time 返回北京时间 date 这一天的 h 点整.
+func (h Hour) Time(date Date) time.Time {
+	return date.Time.Add(time.Duration(h) * time.Hour)
+}
+
+func (h Hour) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(h)*100, 10), nil
+}
+
+func (h *Hour) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > 2300 {
+		return errors.New("invalid ref_hour: " + string(data))
+	}
+	*h = Hour(n / 100)
+	return nil
+}
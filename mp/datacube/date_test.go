@@ -0,0 +1,50 @@
+package datacube
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/chanxuehong/wechat/util"
+)
+
+func TestDateAndHourJSON(t *testing.T) {
+	var data UserReadHourData
+	if err := json.Unmarshal([]byte(`{"ref_date":"2020-01-02","ref_hour":1300,"int_page_read_user":5}`), &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.RefDate != NewDate(2020, 1, 2) {
+		t.Errorf("have RefDate %v", data.RefDate)
+	}
+	if data.RefHour != 13 {
+		t.Errorf("have RefHour %d, want 13", data.RefHour)
+	}
+	if have, want := data.RefHour.Time(data.RefDate), time.Date(2020, 1, 2, 13, 0, 0, 0, util.BeijingLocation); !have.Equal(want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	b, err := json.Marshal(NewRequest(time.Date(2020, 1, 1, 23, 0, 0, 0, time.UTC), time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := string(b), `{"begin_date":"2020-01-01","end_date":"2020-01-03"}`; have != want {
+		t.Errorf("have %s, want %s", have, want)
+	}
+
+	b, err = json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data2 UserReadHourData
+	if err = json.Unmarshal(b, &data2); err != nil {
+		t.Fatal(err)
+	}
+	if data2 != data {
+		t.Errorf("have %+v, want %+v", data2, data)
+	}
+
+	var d Date
+	if err = json.Unmarshal([]byte(`"20200102"`), &d); err == nil {
+		t.Error("want error for invalid date")
+	}
+}
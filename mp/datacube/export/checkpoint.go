@@ -0,0 +1,89 @@
+package export
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chanxuehong/wechat/util"
+)
+
+// CheckpointStore 保存 Exporter.Sync 最后同步成功的日期.
+type CheckpointStore interface {
+	// Load 返回最后同步成功的日期, 如果没有同步记录则 ok 为 false.
+	Load() (date time.Time, ok bool, err error)
+	// Save 保存最后同步成功的日期.
+	Save(date time.Time) error
+}
+
+var _ CheckpointStore = (*MemoryCheckpointStore)(nil)
+
+// MemoryCheckpointStore 是内存实现的 CheckpointStore, 一般用于测试.
+type MemoryCheckpointStore struct {
+	mu   sync.Mutex
+	date time.Time
+}
+
+func (store *MemoryCheckpointStore) Load() (date time.Time, ok bool, err error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.date, !store.date.IsZero(), nil
+}
+
+func (store *MemoryCheckpointStore) Save(date time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.date = date
+	return nil
+}
+
+var _ CheckpointStore = (*FileCheckpointStore)(nil)
+
+// FileCheckpointStore 把日期以 YYYY-MM-DD 格式(北京时间)保存在文件中.
+type FileCheckpointStore struct {
+	path string
+}
+
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+func (store *FileCheckpointStore) Load() (date time.Time, ok bool, err error) {
+	b, err := ioutil.ReadFile(store.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	text := strings.TrimSpace(string(b))
+	if text == "" {
+		return
+	}
+	if date, err = time.ParseInLocation("2006-01-02", text, util.BeijingLocation); err != nil {
+		return
+	}
+	ok = true
+	return
+}
+
+// Save 先写入临时文件再重命名, 保证文件内容的完整.
+func (store *FileCheckpointStore) Save(date time.Time) (err error) {
+	file, err := ioutil.TempFile(filepath.Dir(store.path), filepath.Base(store.path)+".tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = file.WriteString(date.In(util.BeijingLocation).Format("2006-01-02") + "\n"); err != nil {
+		file.Close()
+		return
+	}
+	if err = file.Close(); err != nil {
+		return
+	}
+	return os.Rename(file.Name(), store.path)
+}
@@ -0,0 +1,259 @@
+// 数据统计接口的数据导出工具, 按天把所有的统计数据导出为 CSV 或者 JSON Lines 格式, 支持增量同步.
+//
+//	exporter := &export.Exporter{
+//	    Client:     clt,
+//	    Format:     export.FormatCSV,
+//	    Open:       export.DirOpener("/data/datacube", export.FormatCSV),
+//	    Checkpoint: export.NewFileCheckpointStore("/data/datacube/checkpoint"),
+//	    Start:      time.Date(2020, 1, 1, 0, 0, 0, 0, util.BeijingLocation),
+//	}
+//	// 每天定时调用, 只会拉取上次同步之后到昨日的数据
+//	if _, err := exporter.Sync(time.Time{}); err != nil {
+//	    // TODO: 增加你的代码
+//	}
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chanxuehong/wechat/mp/core"
+	"github.com/chanxuehong/wechat/mp/datacube"
+	datacubecard "github.com/chanxuehong/wechat/mp/datacube/card"
+	"github.com/chanxuehong/wechat/util"
+)
+
+type Format int
+
+const (
+	FormatCSV       Format = iota // CSV, 第一行为列名
+	FormatJSONLines               // JSON Lines, 每行一个 JSON 对象
+)
+
+// Ext 返回格式对应的文件扩展名.
+func (format Format) Ext() string {
+	if format == FormatJSONLines {
+		return ".jsonl"
+	}
+	return ".csv"
+}
+
+// 导出的数据(指标)名称, 也是导出的顺序.
+const (
+	MetricUserSummary          = "user_summary"
+	MetricUserCumulate         = "user_cumulate"
+	MetricArticleSummary       = "article_summary"
+	MetricArticleTotal         = "article_total"
+	MetricUserRead             = "user_read"
+	MetricUserReadHour         = "user_read_hour"
+	MetricUserShare            = "user_share"
+	MetricUserShareHour        = "user_share_hour"
+	MetricUpstreamMsg          = "upstream_msg"
+	MetricUpstreamMsgHour      = "upstream_msg_hour"
+	MetricUpstreamMsgDist      = "upstream_msg_dist"
+	MetricInterfaceSummary     = "interface_summary"
+	MetricInterfaceSummaryHour = "interface_summary_hour"
+	MetricCardBizUin           = "card_biz_uin"
+	MetricCardInfo             = "card_info"
+	MetricMemberCardInfo       = "member_card_info"
+)
+
+type metric struct {
+	name  string
+	fetch func(clt *core.Client, req *datacube.Request, condSource int) (list interface{}, err error)
+}
+
+var metrics = []metric{
+	{MetricUserSummary, func(clt *core.Client, req *datacube.Request, _ int) (interface{}, error) {
+		return datacube.GetUserSummary(clt, req)
+	}},
+	{MetricUserCumulate, func(clt *core.Client, req *datacube.Request, _ int) (interface{}, error) {
+		return datacube.GetUserCumulate(clt, req)
+	}},
+	{MetricArticleSummary, func(clt *core.Client, req *datacube.Request, _ int) (interface{}, error) {
+		return datacube.GetArticleSummary(clt, req)
+	}},
+	{MetricArticleTotal, func(clt *core.Client, req *datacube.Request, _ int) (interface{}, error) {
+		return datacube.GetArticleTotal(clt, req)
+	}},
+	{MetricUserRead, func(clt *core.Client, req *datacube.Request, _ int) (interface{}, error) {
+		return datacube.GetUserRead(clt, req)
+	}},
+	{MetricUserReadHour, func(clt *core.Client, req *datacube.Request, _ int) (interface{}, error) {
+		return datacube.GetUserReadHour(clt, req)
+	}},
+	{MetricUserShare, func(clt *core.Client, req *datacube.Request, _ int) (interface{}, error) {
+		return datacube.GetUserShare(clt, req)
+	}},
+	{MetricUserShareHour, func(clt *core.Client, req *datacube.Request, _ int) (interface{}, error) {
+		return datacube.GetUserShareHour(clt, req)
+	}},
+	{MetricUpstreamMsg, func(clt *core.Client, req *datacube.Request, _ int) (interface{}, error) {
+		return datacube.GetUpstreamMsg(clt, req)
+	}},
+	{MetricUpstreamMsgHour, func(clt *core.Client, req *datacube.Request, _ int) (interface{}, error) {
+		return datacube.GetUpstreamMsgHour(clt, req)
+	}},
+	{MetricUpstreamMsgDist, func(clt *core.Client, req *datacube.Request, _ int) (interface{}, error) {
+		return datacube.GetUpstreamMsgDist(clt, req)
+	}},
+	{MetricInterfaceSummary, func(clt *core.Client, req *datacube.Request, _ int) (interface{}, error) {
+		return datacube.GetInterfaceSummary(clt, req)
+	}},
+	{MetricInterfaceSummaryHour, func(clt *core.Client, req *datacube.Request, _ int) (interface{}, error) {
+		return datacube.GetInterfaceSummaryHour(clt, req)
+	}},
+	{MetricCardBizUin, func(clt *core.Client, req *datacube.Request, condSource int) (interface{}, error) {
+		return datacubecard.GetBizUinInfo(clt, cardRequest(req, condSource))
+	}},
+	{MetricCardInfo, func(clt *core.Client, req *datacube.Request, condSource int) (interface{}, error) {
+		return datacubecard.GetCardInfo(clt, cardRequest(req, condSource))
+	}},
+	{MetricMemberCardInfo, func(clt *core.Client, req *datacube.Request, condSource int) (interface{}, error) {
+		return datacubecard.GetMemberCardInfo(clt, cardRequest(req, condSource))
+	}},
+}
+
+func cardRequest(req *datacube.Request, condSource int) *datacubecard.Request {
+	return &datacubecard.Request{
+		BeginDate:  req.BeginDate,
+		EndDate:    req.EndDate,
+		CondSource: condSource,
+	}
+}
+
+// Metrics 返回所有支持导出的数据名称.
+func Metrics() []string {
+	names := make([]string, len(metrics))
+	for i := range metrics {
+		names[i] = metrics[i].name
+	}
+	return names
+}
+
+// Exporter 按天导出数据统计接口的数据.
+type Exporter struct {
+	Client *core.Client
+	Format Format
+
+	// Open 返回 metric 在 date 这一天的数据的写入对象, 每个 metric 每天调用一次, 写入完成后会调用 Close.
+	Open func(metric string, date time.Time) (io.WriteCloser, error)
+
+	Metrics        []string // 需要导出的数据名称, 为空则导出全部(参考 Metrics 函数)
+	CardCondSource int      // 卡券数据的来源, 0为公众平台创建的卡券数据、1是API创建的卡券数据
+
+	// 以下字段只有 Sync 使用
+	Checkpoint CheckpointStore // 保存最后同步成功的日期, 不能为 nil
+	Start      time.Time       // 没有同步记录的时候从 Start 这一天开始同步
+}
+
+// ExportDate 导出 date(北京时间)这一天所有的数据.
+func (e *Exporter) ExportDate(date time.Time) (err error) {
+	if e.Open == nil {
+		return errors.New("nil Exporter.Open")
+	}
+	date = datacube.DateOf(date.In(util.BeijingLocation)).Time
+	req := datacube.NewRequest(date, date)
+
+	for i := range metrics {
+		m := &metrics[i]
+		if !e.enabled(m.name) {
+			continue
+		}
+		list, err := m.fetch(e.Client, req, e.CardCondSource)
+		if err != nil {
+			return fmt.Errorf("export %s of %s failed: %s", m.name, req.BeginDate, err.Error())
+		}
+		if err = e.write(m.name, date, list); err != nil {
+			return fmt.Errorf("export %s of %s failed: %s", m.name, req.BeginDate, err.Error())
+		}
+	}
+	return nil
+}
+
+func (e *Exporter) enabled(name string) bool {
+	if len(e.Metrics) == 0 {
+		return true
+	}
+	for _, v := range e.Metrics {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Exporter) write(name string, date time.Time, list interface{}) (err error) {
+	w, err := e.Open(name, date)
+	if err != nil {
+		return
+	}
+	if e.Format == FormatJSONLines {
+		err = writeJSONLines(w, list)
+	} else {
+		err = writeCSV(w, list)
+	}
+	if err2 := w.Close(); err == nil {
+		err = err2
+	}
+	return
+}
+
+// Sync 从上次同步成功的日期的下一天(没有同步记录则从 Exporter.Start 开始)同步到 end(北京时间),
+// 每同步成功一天就更新一次同步记录, 返回最后同步成功的日期.
+//
+//	end: 如果为零值或者晚于昨日则同步到昨日(数据统计接口只能获取昨日及以前的数据)
+func (e *Exporter) Sync(end time.Time) (last time.Time, err error) {
+	if e.Checkpoint == nil {
+		err = errors.New("nil Exporter.Checkpoint")
+		return
+	}
+	last, ok, err := e.Checkpoint.Load()
+	if err != nil {
+		return
+	}
+	var date datacube.Date
+	if ok {
+		date = datacube.DateOf(last.In(util.BeijingLocation))
+		last = date.Time
+		date = date.AddDays(1)
+	} else {
+		if e.Start.IsZero() {
+			err = errors.New("no checkpoint and zero Exporter.Start")
+			return
+		}
+		date = datacube.DateOf(e.Start.In(util.BeijingLocation))
+	}
+	endDate := datacube.Yesterday()
+	if !end.IsZero() {
+		if d := datacube.DateOf(end.In(util.BeijingLocation)); d.Before(endDate.Time) {
+			endDate = d
+		}
+	}
+
+	for ; !date.After(endDate.Time); date = date.AddDays(1) {
+		if err = e.ExportDate(date.Time); err != nil {
+			return
+		}
+		if err = e.Checkpoint.Save(date.Time); err != nil {
+			return
+		}
+		last = date.Time
+	}
+	return
+}
+
+// DirOpener 返回一个 Exporter.Open, 把数据写入到文件 dir/YYYY-MM-DD/metric.ext 中.
+func DirOpener(dir string, format Format) func(metric string, date time.Time) (io.WriteCloser, error) {
+	return func(metric string, date time.Time) (io.WriteCloser, error) {
+		subDir := filepath.Join(dir, date.Format("2006-01-02"))
+		if err := os.MkdirAll(subDir, 0755); err != nil {
+			return nil, err
+		}
+		return os.Create(filepath.Join(subDir, metric+format.Ext()))
+	}
+}
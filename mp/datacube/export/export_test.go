@@ -0,0 +1,99 @@
+package export
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+	"github.com/chanxuehong/wechat/mp/datacube"
+)
+
+func TestWriteCSV(t *testing.T) {
+	var item datacube.UserReadHourData
+	item.RefDate = datacube.NewDate(2020, 1, 2)
+	item.RefHour = 13
+	item.IntPageReadUser = 5
+
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, []datacube.UserReadHourData{item}); err != nil {
+		t.Fatal(err)
+	}
+	want := "ref_hour,total_online_time,ref_date,user_source,int_page_read_user,int_page_read_count,ori_page_read_user,ori_page_read_count,share_user,share_count,add_to_fav_user,add_to_fav_count\n" +
+		"1300,0,2020-01-02,0,5,0,0,0,0,0,0,0\n"
+	if have := buf.String(); have != want {
+		t.Errorf("have:\n%s\nwant:\n%s", have, want)
+	}
+
+	buf.Reset()
+	if err := writeCSV(&buf, []datacube.InterfaceSummaryData(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := buf.String(), "ref_date,callback_count,fail_count,total_time_cost,max_time_cost\n"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+func TestSync(t *testing.T) {
+	yesterday := datacube.Yesterday()
+
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, string(body))
+		io.WriteString(w, `{"errcode":0,"list":[{"ref_date":"2020-01-01","cumulate_user":10}]}`)
+	}))
+	defer srv.Close()
+
+	var outputs []string
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoint"))
+	exporter := &Exporter{
+		Client:  testutil.NewClient(srv.URL),
+		Format:  FormatJSONLines,
+		Metrics: []string{MetricUserCumulate},
+		Open: func(metric string, date time.Time) (io.WriteCloser, error) {
+			outputs = append(outputs, metric+"@"+date.Format("2006-01-02"))
+			return nopCloser{ioutil.Discard}, nil
+		},
+		Checkpoint: store,
+		Start:      yesterday.AddDays(-2).Time,
+	}
+
+	last, err := exporter.Sync(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := last.Format("2006-01-02"), yesterday.String(); have != want {
+		t.Errorf("have last %s, want %s", have, want)
+	}
+	want := "user_cumulate@" + yesterday.AddDays(-2).String() +
+		" user_cumulate@" + yesterday.AddDays(-1).String() +
+		" user_cumulate@" + yesterday.String()
+	if have := strings.Join(outputs, " "); have != want {
+		t.Errorf("have %s, want %s", have, want)
+	}
+
+	// 再次同步, 没有需要拉取的数据
+	requests, outputs = nil, nil
+	if _, err = exporter.Sync(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 0 || len(outputs) != 0 {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+
+	date, ok, err := store.Load()
+	if err != nil || !ok || date.Format("2006-01-02") != yesterday.String() {
+		t.Errorf("have checkpoint (%v, %v, %v)", date, ok, err)
+	}
+}
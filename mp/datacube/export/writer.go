@@ -0,0 +1,144 @@
+package export
+
+import (
+	"bufio"
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// column 是结构体中一个导出的字段, index 为 reflect.Value.FieldByIndex 的参数.
+type column struct {
+	name  string
+	index []int
+}
+
+// columns 返回结构体 typ 的所有列, 列名为字段的 json tag 名称, 列的顺序为字段定义的顺序,
+// 匿名嵌入的结构体会被展开.
+func columns(typ reflect.Type) []column {
+	var cols []column
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := tag
+		if j := strings.IndexByte(tag, ','); j >= 0 {
+			name = tag[:j]
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for _, col := range columns(field.Type) {
+				col.index = append([]int{i}, col.index...)
+				cols = append(cols, col)
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		cols = append(cols, column{name: name, index: []int{i}})
+	}
+	return cols
+}
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// formatValue 把 v 格式化为 CSV 的单元格, 和 JSON Lines 格式保持一致:
+// 实现了 encoding.TextMarshaler 的使用 MarshalText 的结果, 实现了 json.Marshaler 的使用 MarshalJSON 的结果(字符串会去掉引号),
+// 数组, 切片, map 和结构体格式化为 JSON.
+func formatValue(v reflect.Value) (string, error) {
+	switch {
+	case v.Type().Implements(textMarshalerType):
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	case v.Type().Implements(jsonMarshalerType):
+		b, err := v.Interface().(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return "", err
+		}
+		if s, err := strconv.Unquote(string(b)); err == nil {
+			return s, nil
+		}
+		return string(b), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Array, reflect.Slice, reflect.Map, reflect.Struct, reflect.Ptr, reflect.Interface:
+		b, err := json.Marshal(v.Interface())
+		return string(b), err
+	default:
+		return "", fmt.Errorf("unsupported type: %s", v.Type())
+	}
+}
+
+// writeCSV 把结构体切片 list 写入 w, 第一行为列名, 没有数据也会写入列名.
+func writeCSV(w io.Writer, list interface{}) (err error) {
+	listValue := reflect.ValueOf(list)
+	if listValue.Kind() != reflect.Slice || listValue.Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unsupported type: %T", list)
+	}
+	cols := columns(listValue.Type().Elem())
+
+	csvWriter := csv.NewWriter(w)
+	record := make([]string, len(cols))
+	for i := range cols {
+		record[i] = cols[i].name
+	}
+	if err = csvWriter.Write(record); err != nil {
+		return
+	}
+	for i := 0; i < listValue.Len(); i++ {
+		item := listValue.Index(i)
+		for j := range cols {
+			if record[j], err = formatValue(item.FieldByIndex(cols[j].index)); err != nil {
+				return
+			}
+		}
+		if err = csvWriter.Write(record); err != nil {
+			return
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// writeJSONLines 把切片 list 的每个元素编码为一行 JSON 写入 w, 字段的顺序为结构体定义的顺序.
+func writeJSONLines(w io.Writer, list interface{}) (err error) {
+	listValue := reflect.ValueOf(list)
+	if listValue.Kind() != reflect.Slice {
+		return fmt.Errorf("unsupported type: %T", list)
+	}
+	bufw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bufw)
+	encoder.SetEscapeHTML(false)
+	for i := 0; i < listValue.Len(); i++ {
+		if err = encoder.Encode(listValue.Index(i).Interface()); err != nil {
+			return
+		}
+	}
+	return bufw.Flush()
+}
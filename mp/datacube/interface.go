@@ -8,11 +8,11 @@ import (
 
 // 接口分析数据
 type InterfaceSummaryData struct {
-	RefDate       string `json:"ref_date"`        // 数据的日期, YYYY-MM-DD 格式
-	CallbackCount int    `json:"callback_count"`  // 通过服务器配置地址获得消息后, 被动回复用户消息的次数
-	FailCount     int    `json:"fail_count"`      // 上述动作的失败次数
-	TotalTimeCost int64  `json:"total_time_cost"` // 总耗时, 除以callback_count即为平均耗时
-	MaxTimeCost   int64  `json:"max_time_cost"`   // 最大耗时
+	RefDate       Date  `json:"ref_date"`        // 数据的日期, YYYY-MM-DD 格式
+	CallbackCount int   `json:"callback_count"`  // 通过服务器配置地址获得消息后, 被动回复用户消息的次数
+	FailCount     int   `json:"fail_count"`      // 上述动作的失败次数
+	TotalTimeCost int64 `json:"total_time_cost"` // 总耗时, 除以callback_count即为平均耗时
+	MaxTimeCost   int64 `json:"max_time_cost"`   // 最大耗时
 }
 
 // 获取接口分析数据.
@@ -41,7 +41,7 @@ func GetInterfaceSummary(clt *core.Client, req *Request) (list []InterfaceSummar
 }
 
 type InterfaceSummaryHourData struct {
-	RefHour int `json:"ref_hour"` // 数据的小时, 包括从000到2300, 分别代表的是[000,100)到[2300,2400), 即每日的第1小时和最后1小时
+	RefHour Hour `json:"ref_hour"` // 数据的小时, 0 到 23; 接口返回的是从000到2300, 分别代表的是[000,100)到[2300,2400), 解码时已经转换
 	InterfaceSummaryData
 }
 
@@ -0,0 +1,137 @@
+package datacube
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/chanxuehong/wechat/mp/core"
+	"github.com/chanxuehong/wechat/util"
+)
+
+// 各接口的最大时间跨度(天), 即 EndDate - BeginDate + 1 的最大值.
+const (
+	MaxSpanUserSummary  = 7
+	MaxSpanUserCumulate = 7
+
+	MaxSpanArticleSummary = 1
+	MaxSpanArticleTotal   = 1
+	MaxSpanUserRead       = 3
+	MaxSpanUserReadHour   = 1
+	MaxSpanUserShare      = 7
+	MaxSpanUserShareHour  = 1
+
+	MaxSpanUpstreamMsg          = 7
+	MaxSpanUpstreamMsgHour      = 1
+	MaxSpanUpstreamMsgWeek      = 30
+	MaxSpanUpstreamMsgMonth     = 30
+	MaxSpanUpstreamMsgDist      = 15
+	MaxSpanUpstreamMsgDistWeek  = 30
+	MaxSpanUpstreamMsgDistMonth = 30
+
+	MaxSpanInterfaceSummary     = 30
+	MaxSpanInterfaceSummaryHour = 1
+)
+
+// MinDate 是数据统计接口能获取的最早的数据日期(2014-12-01, 北京时间).
+var MinDate = time.Date(2014, 12, 1, 0, 0, 0, 0, util.BeijingLocation)
+
+// 方便测试
+var timeNow = time.Now
+
+// Yesterday 返回北京时间的昨日, 数据统计接口只能获取昨日及以前的数据.
+func Yesterday() Date {
+	return DateOf(timeNow().In(util.BeijingLocation)).AddDays(-1)
+}
+
+// SplitDateRange 把 [begin, end] 拆分成多个时间跨度不超过 maxSpan 天的 Request, 按照日期升序排列.
+//
+//	begin, end 都会转换为北京时间的日期; 早于 MinDate 的日期和晚于昨日的日期会被忽略,
+//	如果忽略后没有合法的日期则返回 nil.
+func SplitDateRange(begin, end time.Time, maxSpan int) []*Request {
+	if maxSpan <= 0 {
+		maxSpan = 1
+	}
+	begin = beijingDate(begin)
+	end = beijingDate(end)
+	if begin.Before(MinDate) {
+		begin = MinDate
+	}
+	if yesterday := Yesterday(); end.After(yesterday.Time) {
+		end = yesterday.Time
+	}
+
+	var reqs []*Request
+	for !begin.After(end) {
+		windowEnd := begin.AddDate(0, 0, maxSpan-1)
+		if windowEnd.After(end) {
+			windowEnd = end
+		}
+		reqs = append(reqs, NewRequest(begin, windowEnd))
+		begin = windowEnd.AddDate(0, 0, 1)
+	}
+	return reqs
+}
+
+// FetchRange 把 [begin, end] 按照 maxSpan 拆分成多个 Request, 然后按照日期升序依次调用 fn, 遇到错误立即返回.
+//
+//	GetUserSummaryRange, GetArticleSummaryRange 和 GetUpstreamMsgRange 已经封装好了, 其他接口直接使用 FetchRange.
+func FetchRange(begin, end time.Time, maxSpan int, fn func(req *Request) error) (err error) {
+	if fn == nil {
+		return errors.New("nil fn")
+	}
+	for _, req := range SplitDateRange(begin, end, maxSpan) {
+		if err = fn(req); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// GetUserSummaryRange 获取用户增减数据, [begin, end] 会按照 MaxSpanUserSummary 拆分成多次请求, 返回的数据按照日期升序排列.
+func GetUserSummaryRange(clt *core.Client, begin, end time.Time) (list []UserSummaryData, err error) {
+	err = FetchRange(begin, end, MaxSpanUserSummary, func(req *Request) error {
+		window, err := GetUserSummary(clt, req)
+		list = append(list, window...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].RefDate.Before(list[j].RefDate.Time) })
+	return
+}
+
+// GetArticleSummaryRange 获取图文群发每日数据, [begin, end] 会按照 MaxSpanArticleSummary 拆分成多次请求, 返回的数据按照日期升序排列.
+func GetArticleSummaryRange(clt *core.Client, begin, end time.Time) (list []ArticleSummaryData, err error) {
+	err = FetchRange(begin, end, MaxSpanArticleSummary, func(req *Request) error {
+		window, err := GetArticleSummary(clt, req)
+		list = append(list, window...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].RefDate.Before(list[j].RefDate.Time) })
+	return
+}
+
+// GetUpstreamMsgRange 获取消息发送概况数据, [begin, end] 会按照 MaxSpanUpstreamMsg 拆分成多次请求, 返回的数据按照日期升序排列.
+func GetUpstreamMsgRange(clt *core.Client, begin, end time.Time) (list []UpstreamMsgData, err error) {
+	err = FetchRange(begin, end, MaxSpanUpstreamMsg, func(req *Request) error {
+		window, err := GetUpstreamMsg(clt, req)
+		list = append(list, window...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].RefDate.Before(list[j].RefDate.Time) })
+	return
+}
+
+// =====================================================================================================================
+
+func beijingDate(t time.Time) time.Time {
+	return DateOf(t.In(util.BeijingLocation)).Time
+}
@@ -0,0 +1,101 @@
+package datacube
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+	"github.com/chanxuehong/wechat/util"
+)
+
+func TestSplitDateRange(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2020, 1, 20, 1, 0, 0, 0, util.BeijingLocation) }
+	defer func() { timeNow = time.Now }()
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, util.BeijingLocation)
+	}
+	tests := []struct {
+		begin, end time.Time
+		maxSpan    int
+		want       [][2]string
+	}{
+		{
+			begin:   date(2020, 1, 1),
+			end:     date(2020, 1, 10),
+			maxSpan: 7,
+			want:    [][2]string{{"2020-01-01", "2020-01-07"}, {"2020-01-08", "2020-01-10"}},
+		},
+		{
+			begin:   date(2020, 1, 1),
+			end:     date(2020, 1, 3),
+			maxSpan: 1,
+			want:    [][2]string{{"2020-01-01", "2020-01-01"}, {"2020-01-02", "2020-01-02"}, {"2020-01-03", "2020-01-03"}},
+		},
+		{
+			// end 晚于昨日
+			begin:   date(2020, 1, 18),
+			end:     date(2020, 1, 25),
+			maxSpan: 30,
+			want:    [][2]string{{"2020-01-18", "2020-01-19"}},
+		},
+		{
+			// 2014-11-30 16:00 UTC 是北京时间 2014-12-01
+			begin:   time.Date(2014, 11, 30, 16, 0, 0, 0, time.UTC),
+			end:     date(2014, 12, 1),
+			maxSpan: 7,
+			want:    [][2]string{{"2014-12-01", "2014-12-01"}},
+		},
+		{
+			begin:   date(2014, 1, 1),
+			end:     date(2014, 2, 1),
+			maxSpan: 7,
+			want:    nil,
+		},
+	}
+	for i, tt := range tests {
+		var have [][2]string
+		for _, req := range SplitDateRange(tt.begin, tt.end, tt.maxSpan) {
+			have = append(have, [2]string{req.BeginDate.String(), req.EndDate.String()})
+		}
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("#%d: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+func TestGetArticleSummaryRange(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2020, 1, 20, 1, 0, 0, 0, util.BeijingLocation) }
+	defer func() { timeNow = time.Now }()
+
+	// 按照日期排序, 同一天的数据保持接口返回的顺序
+	var windows []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		windows = append(windows, req.BeginDate.String())
+		date := req.BeginDate.String()
+		fmt.Fprintf(w, `{"list":[{"ref_date":"%s","msgid":"2"},{"ref_date":"%s","msgid":"1"}]}`, date, date)
+	}))
+	defer srv.Close()
+
+	list, err := GetArticleSummaryRange(testutil.NewClient(srv.URL), NewDate(2020, 1, 1).Time, NewDate(2020, 1, 2).Time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var have []string
+	for _, data := range list {
+		have = append(have, data.RefDate.String()+"/"+data.MsgId)
+	}
+	want := []string{"2020-01-01/2", "2020-01-01/1", "2020-01-02/2", "2020-01-02/1"}
+	if !reflect.DeepEqual(have, want) || len(windows) != 2 {
+		t.Errorf("have %v, want %v; windows %v", have, want, windows)
+	}
+}
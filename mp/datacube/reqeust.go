@@ -9,19 +9,19 @@ type Request struct {
 	// 获取数据的起始日期, YYYY-MM-DD 格式;
 	// begin_date 和 end_date 的差值需小于"最大时间跨度"(比如最大时间跨度为1时,
 	// begin_date 和 end_date 的差值只能为0, 才能小于1), 否则会报错.
-	BeginDate string `json:"begin_date,omitempty"`
+	BeginDate Date `json:"begin_date"`
 
 	// 获取数据的结束日期, YYYY-MM-DD 格式;
 	// end_date 允许设置的最大值为昨日.
-	EndDate string `json:"end_date,omitempty"`
+	EndDate Date `json:"end_date"`
 }
 
 // NewRequest 创建一个 Request.
 //
-//	请注意 BeginDate, EndDate 的 Location.
+//	请注意 BeginDate, EndDate 的 Location, 取的是 BeginDate, EndDate 在其自身 Location 下的日期, 参考 DateOf.
 func NewRequest(BeginDate, EndDate time.Time) *Request {
 	return &Request{
-		BeginDate: BeginDate.Format("2006-01-02"),
-		EndDate:   EndDate.Format("2006-01-02"),
+		BeginDate: DateOf(BeginDate),
+		EndDate:   DateOf(EndDate),
 	}
 }
@@ -8,8 +8,8 @@ import (
 
 // 消息发送概况数据
 type UpstreamMsgData struct {
-	RefDate    string `json:"ref_date"`    // 数据的日期, YYYY-MM-DD 格式
-	UserSource int    `json:"user_source"` // 返回的 json 有这个字段, 文档中没有, 都是 0 值, 可能没有实际意义!!!
+	RefDate    Date `json:"ref_date"`    // 数据的日期, YYYY-MM-DD 格式
+	UserSource int  `json:"user_source"` // 返回的 json 有这个字段, 文档中没有, 都是 0 值, 可能没有实际意义!!!
 
 	// 消息类型, 代表含义如下:
 	// 1代表文字
@@ -49,7 +49,7 @@ func GetUpstreamMsg(clt *core.Client, req *Request) (list []UpstreamMsgData, err
 
 // 消息分送分时数据
 type UpstreamMsgHourData struct {
-	RefHour int `json:"ref_hour"` // 数据的小时, 包括从000到2300, 分别代表的是[000,100)到[2300,2400), 即每日的第1小时和最后1小时
+	RefHour Hour `json:"ref_hour"` // 数据的小时, 0 到 23; 接口返回的是从000到2300, 分别代表的是[000,100)到[2300,2400), 解码时已经转换
 	UpstreamMsgData
 }
 
@@ -136,10 +136,10 @@ func GetUpstreamMsgMonth(clt *core.Client, req *Request) (list []UpstreamMsgMont
 
 // 消息发送分布数据
 type UpstreamMsgDistData struct {
-	RefDate       string `json:"ref_date"`       // 数据的日期, YYYY-MM-DD 格式
-	UserSource    int    `json:"user_source"`    // 返回的 json 有这个字段, 文档中没有, 都是 0 值, 可能没有实际意义!!!
-	CountInterval int    `json:"count_interval"` // 当日发送消息量分布的区间, 0代表 "0", 1代表"1-5", 2代表"6-10", 3代表"10次以上"
-	MsgUser       int    `json:"msg_user"`       // 上行发送了(向公众号发送了)消息的用户数
+	RefDate       Date `json:"ref_date"`       // 数据的日期, YYYY-MM-DD 格式
+	UserSource    int  `json:"user_source"`    // 返回的 json 有这个字段, 文档中没有, 都是 0 值, 可能没有实际意义!!!
+	CountInterval int  `json:"count_interval"` // 当日发送消息量分布的区间, 0代表 "0", 1代表"1-5", 2代表"6-10", 3代表"10次以上"
+	MsgUser       int  `json:"msg_user"`       // 上行发送了(向公众号发送了)消息的用户数
 }
 
 // 获取消息发送分布数据.
@@ -8,7 +8,7 @@ import (
 
 // 用户增减数据
 type UserSummaryData struct {
-	RefDate string `json:"ref_date"` // 数据的日期, YYYY-MM-DD 格式
+	RefDate Date `json:"ref_date"` // 数据的日期, YYYY-MM-DD 格式
 
 	// 用户的渠道, 数值代表的含义如下:
 	// 0  代表其他
@@ -54,9 +54,9 @@ func GetUserSummary(clt *core.Client, req *Request) (list []UserSummaryData, err
 
 // 累计用户数据
 type UserCumulateData struct {
-	RefDate      string `json:"ref_date"`      // 数据的日期, YYYY-MM-DD 格式
-	UserSource   int    `json:"user_source"`   // 返回的 json 有这个字段, 文档中没有, 都是 0 值, 可能没有实际意义!!!
-	CumulateUser int    `json:"cumulate_user"` // 总用户量
+	RefDate      Date `json:"ref_date"`      // 数据的日期, YYYY-MM-DD 格式
+	UserSource   int  `json:"user_source"`   // 返回的 json 有这个字段, 文档中没有, 都是 0 值, 可能没有实际意义!!!
+	CumulateUser int  `json:"cumulate_user"` // 总用户量
 }
 
 // 获取累计用户数据.
@@ -0,0 +1,274 @@
+// 草稿箱.
+//
+//	草稿箱和发布能力(freepublish 模块)取代了 material 模块的 AddNews, UpdateNews 等永久图文素材接口;
+//	草稿内容中的图片, 可以调用 base.UploadImage 或者 base.UploadImageFromReader 来上传.
+package draft
+
+import (
+	"fmt"
+
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+type Article struct {
+	Title              string `json:"title"`                        // 标题
+	Author             string `json:"author,omitempty"`             // 作者
+	Digest             string `json:"digest,omitempty"`             // 图文消息的摘要, 仅有单图文消息才有摘要, 多图文此处为空; 如果本字段为没有填写, 则默认抓取正文前54个字
+	Content            string `json:"content"`                      // 图文消息的具体内容, 支持HTML标签, 必须少于2万字符, 小于1M, 且此处会去除JS
+	ContentSourceURL   string `json:"content_source_url,omitempty"` // 图文消息的原文地址, 即点击"阅读原文"后的URL
+	ThumbMediaId       string `json:"thumb_media_id"`               // 图文消息的封面图片素材id(必须是永久MediaID)
+	NeedOpenComment    int    `json:"need_open_comment"`            // 是否打开评论, 0不打开(默认), 1打开
+	OnlyFansCanComment int    `json:"only_fans_can_comment"`        // 是否粉丝才可评论, 0所有人可评论(默认), 1粉丝才可评论
+	PicCrop2351        string `json:"pic_crop_235_1,omitempty"`     // 封面裁剪为2.35:1规格的坐标字段, 以原始图片左上角为原点, 格式为 X1_Y1_X2_Y2
+	PicCrop11          string `json:"pic_crop_1_1,omitempty"`       // 封面裁剪为1:1规格的坐标字段, 格式同上
+	URL                string `json:"url,omitempty"`                // !!!创建时不需要此参数!!! 草稿的临时链接
+	ThumbURL           string `json:"thumb_url,omitempty"`          // !!!创建时不需要此参数!!! 封面图片的URL
+}
+
+// Add 新建草稿, 返回草稿的 media_id.
+func Add(clt *core.Client, articles []Article) (mediaId string, err error) {
+	const incompleteURL = "https://api.weixin.qq.com/cgi-bin/draft/add?access_token="
+
+	var request = struct {
+		Articles []Article `json:"articles"`
+	}{
+		Articles: articles,
+	}
+	var result struct {
+		core.Error
+		MediaId string `json:"media_id"`
+	}
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	mediaId = result.MediaId
+	return
+}
+
+// Get 获取草稿.
+func Get(clt *core.Client, mediaId string) (articles []Article, err error) {
+	const incompleteURL = "https://api.weixin.qq.com/cgi-bin/draft/get?access_token="
+
+	var request = struct {
+		MediaId string `json:"media_id"`
+	}{
+		MediaId: mediaId,
+	}
+	var result struct {
+		core.Error
+		Articles []Article `json:"news_item"`
+	}
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	articles = result.Articles
+	return
+}
+
+// Delete 删除草稿.
+func Delete(clt *core.Client, mediaId string) (err error) {
+	const incompleteURL = "https://api.weixin.qq.com/cgi-bin/draft/delete?access_token="
+
+	var request = struct {
+		MediaId string `json:"media_id"`
+	}{
+		MediaId: mediaId,
+	}
+	var result core.Error
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result
+		return
+	}
+	return
+}
+
+// Update 修改草稿.
+//
+//	index: 要更新的文章在图文消息中的位置(多图文消息时, 此字段才有意义), 第一篇为0
+func Update(clt *core.Client, mediaId string, index int, article *Article) (err error) {
+	const incompleteURL = "https://api.weixin.qq.com/cgi-bin/draft/update?access_token="
+
+	var request = struct {
+		MediaId string   `json:"media_id"`
+		Index   int      `json:"index"`
+		Article *Article `json:"articles,omitempty"`
+	}{
+		MediaId: mediaId,
+		Index:   index,
+		Article: article,
+	}
+	var result core.Error
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result
+		return
+	}
+	return
+}
+
+// Count 获取草稿的总数.
+func Count(clt *core.Client) (total int, err error) {
+	const incompleteURL = "https://api.weixin.qq.com/cgi-bin/draft/count?access_token="
+
+	var result struct {
+		core.Error
+		TotalCount int `json:"total_count"`
+	}
+	if err = clt.GetJSON(incompleteURL, &result); err != nil {
+		return
+	}
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	total = result.TotalCount
+	return
+}
+
+type BatchGetResult struct {
+	TotalCount int         `json:"total_count"` // 草稿的总数
+	ItemCount  int         `json:"item_count"`  // 本次调用获取的草稿的数量
+	Items      []DraftInfo `json:"item"`        // 本次调用获取的草稿列表
+}
+
+type DraftInfo struct {
+	MediaId    string `json:"media_id"`    // 草稿的 media_id
+	UpdateTime int64  `json:"update_time"` // 最后更新时间
+	Content    struct {
+		Articles []Article `json:"news_item,omitempty"`
+	} `json:"content"`
+}
+
+// BatchGet 获取草稿列表.
+//
+//	offset:    从全部素材的该偏移位置开始返回, 0表示从第一个素材
+//	count:     返回素材的数量, 取值在1到20之间
+//	noContent: 为 true 时不返回 content 字段
+func BatchGet(clt *core.Client, offset, count int, noContent bool) (rslt *BatchGetResult, err error) {
+	const incompleteURL = "https://api.weixin.qq.com/cgi-bin/draft/batchget?access_token="
+
+	if offset < 0 {
+		err = fmt.Errorf("incorrect offset: %d", offset)
+		return
+	}
+	if count <= 0 || count > 20 {
+		err = fmt.Errorf("incorrect count: %d", count)
+		return
+	}
+
+	var request = struct {
+		Offset    int `json:"offset"`
+		Count     int `json:"count"`
+		NoContent int `json:"no_content"`
+	}{
+		Offset: offset,
+		Count:  count,
+	}
+	if noContent {
+		request.NoContent = 1
+	}
+	var result struct {
+		core.Error
+		BatchGetResult
+	}
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	rslt = &result.BatchGetResult
+	return
+}
+
+// =====================================================================================================================
+
+// DraftIterator
+//
+//	iter, err := NewDraftIterator(clt, 0, 10, false)
+//	if err != nil {
+//	    // TODO: 增加你的代码
+//	}
+//
+//	for iter.HasNext() {
+//	    items, err := iter.NextPage()
+//	    if err != nil {
+//	        // TODO: 增加你的代码
+//	    }
+//	    // TODO: 增加你的代码
+//	}
+type DraftIterator struct {
+	clt *core.Client
+
+	nextOffset int
+	count      int
+	noContent  bool
+
+	lastBatchGetResult *BatchGetResult
+	nextPageCalled     bool
+}
+
+func (iter *DraftIterator) TotalCount() int {
+	return iter.lastBatchGetResult.TotalCount
+}
+
+func (iter *DraftIterator) HasNext() bool {
+	if !iter.nextPageCalled {
+		return iter.lastBatchGetResult.ItemCount > 0 || iter.nextOffset < iter.lastBatchGetResult.TotalCount
+	}
+	return iter.nextOffset < iter.lastBatchGetResult.TotalCount
+}
+
+func (iter *DraftIterator) NextPage() (items []DraftInfo, err error) {
+	if !iter.nextPageCalled {
+		iter.nextPageCalled = true
+		items = iter.lastBatchGetResult.Items
+		return
+	}
+
+	rslt, err := BatchGet(iter.clt, iter.nextOffset, iter.count, iter.noContent)
+	if err != nil {
+		return
+	}
+
+	iter.lastBatchGetResult = rslt
+	iter.nextOffset += rslt.ItemCount
+
+	items = rslt.Items
+	return
+}
+
+func NewDraftIterator(clt *core.Client, offset, count int, noContent bool) (iter *DraftIterator, err error) {
+	// 逻辑上相当于第一次调用 DraftIterator.NextPage,
+	// 因为第一次调用 DraftIterator.HasNext 需要数据支撑, 所以提前获取了数据
+	rslt, err := BatchGet(clt, offset, count, noContent)
+	if err != nil {
+		return
+	}
+
+	iter = &DraftIterator{
+		clt: clt,
+
+		nextOffset: offset + rslt.ItemCount,
+		count:      count,
+		noContent:  noContent,
+
+		lastBatchGetResult: rslt,
+		nextPageCalled:     false,
+	}
+	return
+}
@@ -0,0 +1,66 @@
+package draft
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+)
+
+func TestDraftIterator(t *testing.T) {
+	const total = 5
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/cgi-bin/draft/batchget" {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			Offset    int `json:"offset"`
+			Count     int `json:"count"`
+			NoContent int `json:"no_content"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		if req.NoContent != 1 {
+			t.Errorf("no_content = %d", req.NoContent)
+		}
+		var items []string
+		for i := req.Offset; i < req.Offset+req.Count && i < total; i++ {
+			items = append(items, fmt.Sprintf(`{"media_id":"m%d","update_time":%d}`, i, 1600000000+i))
+		}
+		fmt.Fprintf(w, `{"total_count":%d,"item_count":%d,"item":[%s]}`, total, len(items), strings.Join(items, ","))
+	}))
+	defer srv.Close()
+	clt := testutil.NewClient(srv.URL)
+
+	iter, err := NewDraftIterator(clt, 0, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iter.TotalCount() != total {
+		t.Errorf("TotalCount = %d", iter.TotalCount())
+	}
+	var ids []string
+	for iter.HasNext() {
+		items, err := iter.NextPage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range items {
+			ids = append(ids, item.MediaId)
+		}
+	}
+	if got := strings.Join(ids, ","); got != "m0,m1,m2,m3,m4" {
+		t.Errorf("media ids = %s", got)
+	}
+
+	if _, err = BatchGet(clt, 0, 21, false); err == nil {
+		t.Error("want error for count > 20")
+	}
+}
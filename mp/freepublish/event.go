@@ -0,0 +1,53 @@
+package freepublish
+
+import (
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+const (
+	EventTypePublishJobFinish core.EventType = "PUBLISHJOBFINISH" // 发布任务完成事件
+)
+
+// 发布任务完成事件
+type PublishJobFinishEvent struct {
+	XMLName struct{} `xml:"xml" json:"-"`
+	core.MsgHeader
+	EventType        core.EventType `xml:"Event"            json:"Event"`
+	PublishEventInfo PublishInfo    `xml:"PublishEventInfo" json:"PublishEventInfo"`
+}
+
+// GetPublishJobFinishEvent 从 msg 中获取发布任务完成事件, 一般在 core.ServeMux 注册的 EventTypePublishJobFinish 的 Handler 中调用:
+//
+//	mux.EventHandleFunc(freepublish.EventTypePublishJobFinish, func(ctx *core.Context) {
+//	    event := freepublish.GetPublishJobFinishEvent(ctx.MixedMsg)
+//	    // TODO: 增加你的代码
+//	})
+func GetPublishJobFinishEvent(msg *core.MixedMsg) *PublishJobFinishEvent {
+	event := &PublishJobFinishEvent{
+		MsgHeader: msg.MsgHeader,
+		EventType: msg.EventType,
+	}
+	info := msg.PublishEventInfo
+	if info == nil {
+		return event
+	}
+	event.PublishEventInfo = PublishInfo{
+		PublishId:     info.PublishId,
+		PublishStatus: info.PublishStatus,
+		ArticleId:     info.ArticleId,
+		FailIndexes:   info.FailIndexes,
+	}
+	if detail := info.ArticleDetail; detail != nil {
+		event.PublishEventInfo.ArticleDetail = &ArticleDetail{
+			Count: detail.Count,
+			Items: make([]ArticleDetailItem, 0, len(detail.Items)),
+		}
+		for _, item := range detail.Items {
+			event.PublishEventInfo.ArticleDetail.Items = append(event.PublishEventInfo.ArticleDetail.Items, ArticleDetailItem{
+				Index:      item.Index,
+				ArticleURL: item.ArticleURL,
+			})
+		}
+	}
+	return event
+}
@@ -0,0 +1,99 @@
+package freepublish
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+func TestPublishJobFinishEvent(t *testing.T) {
+	msg := []byte(`<xml><ToUserName><![CDATA[gh_4d00ed8d6399]]></ToUserName>
+<FromUserName><![CDATA[oV5CrjpxgaGXNHIQigzNlgLTnwic]]></FromUserName>
+<CreateTime>1481013459</CreateTime>
+<MsgType><![CDATA[event]]></MsgType>
+<Event><![CDATA[PUBLISHJOBFINISH]]></Event>
+<PublishEventInfo>
+<publish_id>2247503051</publish_id>
+<publish_status>0</publish_status>
+<article_id><![CDATA[b5O2OUs25HBxRceL7hfReg]]></article_id>
+<article_detail>
+<count>2</count>
+<item>
+<idx>1</idx>
+<article_url><![CDATA[https://mp.weixin.qq.com/s/1]]></article_url>
+</item>
+<item>
+<idx>2</idx>
+<article_url><![CDATA[https://mp.weixin.qq.com/s/2]]></article_url>
+</item>
+</article_detail>
+</PublishEventInfo>
+</xml>`)
+
+	var mixedMsg = &core.MixedMsg{}
+	if err := xml.Unmarshal(msg, mixedMsg); err != nil {
+		t.Errorf("unmarshal failed: %s\n", err.Error())
+		return
+	}
+	var haveObject = GetPublishJobFinishEvent(mixedMsg)
+
+	var wantObject = &PublishJobFinishEvent{
+		MsgHeader: core.MsgHeader{
+			ToUserName:   "gh_4d00ed8d6399",
+			FromUserName: "oV5CrjpxgaGXNHIQigzNlgLTnwic",
+			CreateTime:   1481013459,
+			MsgType:      "event",
+		},
+		EventType: EventTypePublishJobFinish,
+		PublishEventInfo: PublishInfo{
+			PublishId:     "2247503051",
+			PublishStatus: PublishStatusSuccess,
+			ArticleId:     "b5O2OUs25HBxRceL7hfReg",
+			ArticleDetail: &ArticleDetail{
+				Count: 2,
+				Items: []ArticleDetailItem{
+					{Index: 1, ArticleURL: "https://mp.weixin.qq.com/s/1"},
+					{Index: 2, ArticleURL: "https://mp.weixin.qq.com/s/2"},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(haveObject, wantObject) {
+		t.Errorf("compare failed,\nhave:\n%+v\nwant:\n%+v\n", haveObject, wantObject)
+		return
+	}
+}
+
+func TestPublishJobFinishEventFail(t *testing.T) {
+	msg := []byte(`<xml><ToUserName><![CDATA[gh_4d00ed8d6399]]></ToUserName>
+<FromUserName><![CDATA[oV5CrjpxgaGXNHIQigzNlgLTnwic]]></FromUserName>
+<CreateTime>1481013459</CreateTime>
+<MsgType><![CDATA[event]]></MsgType>
+<Event><![CDATA[PUBLISHJOBFINISH]]></Event>
+<PublishEventInfo>
+<publish_id>2247503051</publish_id>
+<publish_status>2</publish_status>
+<fail_idx>1</fail_idx>
+<fail_idx>2</fail_idx>
+</PublishEventInfo>
+</xml>`)
+
+	var mixedMsg = &core.MixedMsg{}
+	if err := xml.Unmarshal(msg, mixedMsg); err != nil {
+		t.Errorf("unmarshal failed: %s\n", err.Error())
+		return
+	}
+	var haveObject = GetPublishJobFinishEvent(mixedMsg).PublishEventInfo
+
+	var wantObject = PublishInfo{
+		PublishId:     "2247503051",
+		PublishStatus: PublishStatusOriginalFail,
+		FailIndexes:   []int{1, 2},
+	}
+	if !reflect.DeepEqual(haveObject, wantObject) {
+		t.Errorf("compare failed,\nhave:\n%+v\nwant:\n%+v\n", haveObject, wantObject)
+		return
+	}
+}
@@ -0,0 +1,281 @@
+// 发布能力.
+//
+//	把草稿箱(draft 模块)中的草稿发布出去, 发布的结果通过 PUBLISHJOBFINISH 事件推送, 也可以调用 Get 轮询.
+package freepublish
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/chanxuehong/wechat/mp/core"
+	"github.com/chanxuehong/wechat/mp/draft"
+)
+
+// 发布状态
+const (
+	PublishStatusSuccess             = 0 // 成功
+	PublishStatusPublishing          = 1 // 发布中
+	PublishStatusOriginalFail        = 2 // 原创失败
+	PublishStatusFail                = 3 // 常规失败
+	PublishStatusAuditFail           = 4 // 平台审核不通过
+	PublishStatusDeletedAfterSuccess = 5 // 成功后用户删除所有文章
+	PublishStatusBannedAfterSuccess  = 6 // 成功后系统封禁所有文章
+)
+
+// Submit 发布草稿, 返回发布任务的 id.
+//
+//	NOTE: 返回成功只表示发布任务提交成功, 发布的结果需要等待 PUBLISHJOBFINISH 事件或者调用 Get 查询.
+func Submit(clt *core.Client, mediaId string) (publishId string, err error) {
+	const incompleteURL = "https://api.weixin.qq.com/cgi-bin/freepublish/submit?access_token="
+
+	var request = struct {
+		MediaId string `json:"media_id"`
+	}{
+		MediaId: mediaId,
+	}
+	var result struct {
+		core.Error
+		PublishId json.Number `json:"publish_id"`
+	}
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	publishId = result.PublishId.String()
+	return
+}
+
+type PublishInfo struct {
+	PublishId     string         `xml:"publish_id"               json:"publish_id"`               // 发布任务id
+	PublishStatus int            `xml:"publish_status"           json:"publish_status"`           // 发布状态, 参考 PublishStatusXXX
+	ArticleId     string         `xml:"article_id"               json:"article_id"`               // 当发布状态为 0 时(即成功)时, 返回图文的 article_id, 可用于"客服消息"场景
+	ArticleDetail *ArticleDetail `xml:"article_detail,omitempty" json:"article_detail,omitempty"` // 当发布状态为 0 时(即成功)时, 返回文章的 url
+	FailIndexes   []int          `xml:"fail_idx,omitempty"       json:"fail_idx,omitempty"`       // 当发布状态为 2 或 4 时, 返回不通过的文章编号, 第一篇为 1; 其他发布状态则为空
+}
+
+type ArticleDetail struct {
+	Count int                 `xml:"count" json:"count"` // 文章数量
+	Items []ArticleDetailItem `xml:"item"  json:"item"`
+}
+
+type ArticleDetailItem struct {
+	Index      int    `xml:"idx"         json:"idx"`         // 文章对应的编号, 第一篇为 1
+	ArticleURL string `xml:"article_url" json:"article_url"` // 图文的永久链接
+}
+
+// Get 查询发布任务的状态.
+func Get(clt *core.Client, publishId string) (info *PublishInfo, err error) {
+	const incompleteURL = "https://api.weixin.qq.com/cgi-bin/freepublish/get?access_token="
+
+	var request = struct {
+		PublishId string `json:"publish_id"`
+	}{
+		PublishId: publishId,
+	}
+	var result struct {
+		core.Error
+		PublishId json.Number `json:"publish_id"` // 可能是字符串也可能是数字
+		PublishInfo
+	}
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	result.PublishInfo.PublishId = result.PublishId.String()
+	info = &result.PublishInfo
+	return
+}
+
+// Delete 删除发布的文章.
+//
+//	index: 要删除的文章在图文消息中的位置, 第一篇编号为1, 该字段不填或填0会删除全部文章
+func Delete(clt *core.Client, articleId string, index int) (err error) {
+	const incompleteURL = "https://api.weixin.qq.com/cgi-bin/freepublish/delete?access_token="
+
+	var request = struct {
+		ArticleId string `json:"article_id"`
+		Index     int    `json:"index,omitempty"`
+	}{
+		ArticleId: articleId,
+		Index:     index,
+	}
+	var result core.Error
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result
+		return
+	}
+	return
+}
+
+type Article struct {
+	draft.Article
+	IsDeleted bool `json:"is_deleted"` // 该图文是否被删除
+}
+
+// GetArticle 通过 article_id 获取已发布文章.
+func GetArticle(clt *core.Client, articleId string) (articles []Article, err error) {
+	const incompleteURL = "https://api.weixin.qq.com/cgi-bin/freepublish/getarticle?access_token="
+
+	var request = struct {
+		ArticleId string `json:"article_id"`
+	}{
+		ArticleId: articleId,
+	}
+	var result struct {
+		core.Error
+		Articles []Article `json:"news_item"`
+	}
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	articles = result.Articles
+	return
+}
+
+type BatchGetResult struct {
+	TotalCount int             `json:"total_count"` // 成功发布的图文的总数
+	ItemCount  int             `json:"item_count"`  // 本次调用获取的图文的数量
+	Items      []PublishedInfo `json:"item"`        // 本次调用获取的图文列表
+}
+
+type PublishedInfo struct {
+	ArticleId  string `json:"article_id"`  // 成功发布的图文的 article_id
+	UpdateTime int64  `json:"update_time"` // 最后更新时间
+	Content    struct {
+		Articles []Article `json:"news_item,omitempty"`
+	} `json:"content"`
+}
+
+// BatchGet 获取成功发布的图文列表.
+//
+//	offset:    从全部素材的该偏移位置开始返回, 0表示从第一个素材
+//	count:     返回素材的数量, 取值在1到20之间
+//	noContent: 为 true 时不返回 content 字段
+func BatchGet(clt *core.Client, offset, count int, noContent bool) (rslt *BatchGetResult, err error) {
+	const incompleteURL = "https://api.weixin.qq.com/cgi-bin/freepublish/batchget?access_token="
+
+	if offset < 0 {
+		err = fmt.Errorf("incorrect offset: %d", offset)
+		return
+	}
+	if count <= 0 || count > 20 {
+		err = fmt.Errorf("incorrect count: %d", count)
+		return
+	}
+
+	var request = struct {
+		Offset    int `json:"offset"`
+		Count     int `json:"count"`
+		NoContent int `json:"no_content"`
+	}{
+		Offset: offset,
+		Count:  count,
+	}
+	if noContent {
+		request.NoContent = 1
+	}
+	var result struct {
+		core.Error
+		BatchGetResult
+	}
+	if err = clt.PostJSON(incompleteURL, &request, &result); err != nil {
+		return
+	}
+	if result.ErrCode != core.ErrCodeOK {
+		err = &result.Error
+		return
+	}
+	rslt = &result.BatchGetResult
+	return
+}
+
+// =====================================================================================================================
+
+// PublishedIterator
+//
+//	iter, err := NewPublishedIterator(clt, 0, 10, false)
+//	if err != nil {
+//	    // TODO: 增加你的代码
+//	}
+//
+//	for iter.HasNext() {
+//	    items, err := iter.NextPage()
+//	    if err != nil {
+//	        // TODO: 增加你的代码
+//	    }
+//	    // TODO: 增加你的代码
+//	}
+type PublishedIterator struct {
+	clt *core.Client
+
+	nextOffset int
+	count      int
+	noContent  bool
+
+	lastBatchGetResult *BatchGetResult
+	nextPageCalled     bool
+}
+
+func (iter *PublishedIterator) TotalCount() int {
+	return iter.lastBatchGetResult.TotalCount
+}
+
+func (iter *PublishedIterator) HasNext() bool {
+	if !iter.nextPageCalled {
+		return iter.lastBatchGetResult.ItemCount > 0 || iter.nextOffset < iter.lastBatchGetResult.TotalCount
+	}
+	return iter.nextOffset < iter.lastBatchGetResult.TotalCount
+}
+
+func (iter *PublishedIterator) NextPage() (items []PublishedInfo, err error) {
+	if !iter.nextPageCalled {
+		iter.nextPageCalled = true
+		items = iter.lastBatchGetResult.Items
+		return
+	}
+
+	rslt, err := BatchGet(iter.clt, iter.nextOffset, iter.count, iter.noContent)
+	if err != nil {
+		return
+	}
+
+	iter.lastBatchGetResult = rslt
+	iter.nextOffset += rslt.ItemCount
+
+	items = rslt.Items
+	return
+}
+
+func NewPublishedIterator(clt *core.Client, offset, count int, noContent bool) (iter *PublishedIterator, err error) {
+	// 逻辑上相当于第一次调用 PublishedIterator.NextPage,
+	// 因为第一次调用 PublishedIterator.HasNext 需要数据支撑, 所以提前获取了数据
+	rslt, err := BatchGet(clt, offset, count, noContent)
+	if err != nil {
+		return
+	}
+
+	iter = &PublishedIterator{
+		clt: clt,
+
+		nextOffset: offset + rslt.ItemCount,
+		count:      count,
+		noContent:  noContent,
+
+		lastBatchGetResult: rslt,
+		nextPageCalled:     false,
+	}
+	return
+}
@@ -0,0 +1,43 @@
+package freepublish
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+)
+
+func TestSubmitAndGet(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cgi-bin/freepublish/submit":
+			fmt.Fprint(w, `{"errcode":0,"errmsg":"ok","publish_id":100000001,"msg_data_id":2247483674}`)
+		case "/cgi-bin/freepublish/get":
+			fmt.Fprint(w, `{"publish_id":"100000001","publish_status":0,"article_id":"a1",`+
+				`"article_detail":{"count":1,"item":[{"idx":1,"article_url":"https://mp.weixin.qq.com/s/1"}]},"fail_idx":[]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	clt := testutil.NewClient(srv.URL)
+
+	publishId, err := Submit(clt, "media")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if publishId != "100000001" {
+		t.Errorf("publishId = %q", publishId)
+	}
+
+	info, err := Get(clt, publishId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.PublishId != "100000001" || info.PublishStatus != PublishStatusSuccess || info.ArticleId != "a1" ||
+		info.ArticleDetail == nil || len(info.ArticleDetail.Items) != 1 || info.ArticleDetail.Items[0].Index != 1 {
+		t.Errorf("info = %+v", info)
+	}
+}
@@ -0,0 +1,236 @@
+// 永久素材的备份和恢复.
+//
+//	备份: 遍历公众号全部的图片, 语音, 视频和图文素材, 下载到本地目录, 并且生成清单文件(manifest.json);
+//	恢复: 根据清单文件把素材重新上传到另一个公众号, 图文素材恢复为草稿, 并且把其中的 thumb_media_id 替换为新的素材id.
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/chanxuehong/wechat/mp/core"
+	"github.com/chanxuehong/wechat/mp/material"
+	"github.com/chanxuehong/wechat/mp/media"
+	"github.com/chanxuehong/wechat/util"
+)
+
+// 每次获取素材列表的数量, 接口允许的最大值
+const batchGetCount = 20
+
+// Backup 备份 clt 对应公众号的全部永久素材到 dir 目录.
+//
+//	如果 dir 目录中已经有清单文件(上一次的备份), 那么 media_id 和 update_time 都没有变化并且文件存在的素材不会重新下载,
+//	所以可以定期调用 Backup 来同步素材.
+func Backup(clt *core.Client, dir string) (manifest *Manifest, err error) {
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	old, err := LoadManifest(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return
+		}
+		old, err = &Manifest{}, nil
+	}
+
+	b := &backuper{clt: clt, dir: dir}
+	m := &Manifest{}
+	if m.Images, err = b.backupItems(material.MaterialTypeImage, old.Images); err != nil {
+		return
+	}
+	if m.Voices, err = b.backupItems(material.MaterialTypeVoice, old.Voices); err != nil {
+		return
+	}
+	if m.Videos, err = b.backupItems(material.MaterialTypeVideo, old.Videos); err != nil {
+		return
+	}
+	if m.News, err = b.backupNews(); err != nil {
+		return
+	}
+	if err = m.save(dir); err != nil {
+		return
+	}
+	manifest = m
+	return
+}
+
+type backuper struct {
+	clt *core.Client
+	dir string
+}
+
+func (b *backuper) backupItems(materialType string, oldItems []Item) (items []Item, err error) {
+	oldItemMap := make(map[string]*Item, len(oldItems))
+	for i := range oldItems {
+		oldItemMap[oldItems[i].MediaId] = &oldItems[i]
+	}
+	if err = os.MkdirAll(filepath.Join(b.dir, materialType), 0755); err != nil {
+		return
+	}
+
+	iter, err := material.NewMaterialIterator(b.clt, materialType, 0, batchGetCount)
+	if err != nil {
+		return
+	}
+	for iter.HasNext() {
+		infos, err := iter.NextPage()
+		if err != nil {
+			return nil, err
+		}
+		for i := range infos {
+			info := &infos[i]
+			if old := oldItemMap[info.MediaId]; old != nil && old.UpdateTime == info.UpdateTime && b.exists(old.File) {
+				items = append(items, *old)
+				continue
+			}
+			item, err := b.backupItem(materialType, info)
+			if err != nil {
+				return nil, fmt.Errorf("backup %s %s failed: %s", materialType, info.MediaId, err.Error())
+			}
+			items = append(items, *item)
+		}
+	}
+	return
+}
+
+func (b *backuper) backupItem(materialType string, info *material.MaterialInfo) (item *Item, err error) {
+	item = &Item{
+		MediaId:    info.MediaId,
+		Name:       info.Name,
+		UpdateTime: info.UpdateTime,
+		URL:        info.URL,
+	}
+
+	if materialType != material.MaterialTypeVideo {
+		if ext := filepath.Ext(info.Name); ext != "" {
+			item.File = path.Join(materialType, info.MediaId+ext)
+			err = b.writeFile(item.File, func(w io.Writer) error {
+				_, err := material.DownloadToWriter(b.clt, info.MediaId, w)
+				return err
+			})
+			return
+		}
+
+		// 素材名称没有扩展名, 先下载到内存, 根据文件内容确定扩展名, 恢复的时候微信根据扩展名判断文件类型
+		var buf bytes.Buffer
+		if _, err = material.DownloadToWriter(b.clt, info.MediaId, &buf); err != nil {
+			return
+		}
+		item.File = path.Join(materialType, info.MediaId)
+		if format := media.DetectFormat(buf.Bytes()); format != "" {
+			item.File += "." + format
+		}
+		err = b.writeFile(item.File, func(w io.Writer) error {
+			_, err := buf.WriteTo(w)
+			return err
+		})
+		return
+	}
+
+	// 视频素材先获取下载地址再下载
+	video, err := material.GetVideo(b.clt, info.MediaId)
+	if err != nil {
+		return
+	}
+	item.Title = video.Title
+	item.Desc = video.Description
+	item.File = path.Join(materialType, info.MediaId+".mp4")
+	err = b.writeFile(item.File, func(w io.Writer) error {
+		return b.httpGet(video.DownloadURL, w)
+	})
+	return
+}
+
+func (b *backuper) backupNews() (newsList []News, err error) {
+	iter, err := material.NewNewsIterator(b.clt, 0, batchGetCount)
+	if err != nil {
+		return
+	}
+	for iter.HasNext() {
+		infos, err := iter.NextPage()
+		if err != nil {
+			return nil, err
+		}
+		for i := range infos {
+			info := &infos[i]
+			news := News{
+				MediaId:    info.MediaId,
+				UpdateTime: info.UpdateTime,
+				Articles:   make([]Article, len(info.Content.Articles)),
+			}
+			if err = os.MkdirAll(filepath.Join(b.dir, "news", info.MediaId), 0755); err != nil {
+				return nil, err
+			}
+			for j := range info.Content.Articles {
+				src := &info.Content.Articles[j]
+				news.Articles[j] = Article{
+					ThumbMediaId:     src.ThumbMediaId,
+					Title:            src.Title,
+					Author:           src.Author,
+					Digest:           src.Digest,
+					ContentFile:      path.Join("news", info.MediaId, fmt.Sprintf("%d.html", j)),
+					ContentSourceURL: src.ContentSourceURL,
+					ShowCoverPic:     src.ShowCoverPic,
+					URL:              src.URL,
+				}
+				err = b.writeFile(news.Articles[j].ContentFile, func(w io.Writer) error {
+					_, err := io.WriteString(w, src.Content)
+					return err
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+			newsList = append(newsList, news)
+		}
+	}
+	return
+}
+
+func (b *backuper) exists(name string) bool {
+	_, err := os.Stat(filepath.Join(b.dir, filepath.FromSlash(name)))
+	return err == nil
+}
+
+// writeFile 把 write 写入的内容保存到备份目录中的 name 文件, 失败时删除文件.
+func (b *backuper) writeFile(name string, write func(w io.Writer) error) (err error) {
+	filename := filepath.Join(b.dir, filepath.FromSlash(name))
+	file, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(file.Name())
+
+	if err = write(file); err != nil {
+		file.Close()
+		return
+	}
+	if err = file.Close(); err != nil {
+		return
+	}
+	return os.Rename(file.Name(), filename)
+}
+
+func (b *backuper) httpGet(url string, w io.Writer) (err error) {
+	httpClient := b.clt.HttpClient
+	if httpClient == nil {
+		httpClient = util.DefaultMediaHttpClient
+	}
+	httpResp, err := httpClient.Get(url)
+	if err != nil {
+		return
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http.Status: %s", httpResp.Status)
+	}
+	_, err = io.Copy(w, httpResp.Body)
+	return
+}
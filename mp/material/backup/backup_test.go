@@ -0,0 +1,134 @@
+package backup
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+)
+
+// fakeServer 模拟素材接口, 源公众号有一张图片, 一个没有扩展名的语音, 一个视频和一个引用了该图片的图文素材.
+type fakeServer struct {
+	downloads int
+	uploads   []string // 上传的文件名和内容
+	news      []string // 新建草稿的请求
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/cgi-bin/material/batchget_material":
+		var req struct {
+			Type string `json:"type"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.Type {
+		case "image":
+			io.WriteString(w, `{"total_count":1,"item_count":1,"item":[{"media_id":"IMG1","name":"a.jpg","update_time":1,"url":"http://img/a.jpg"}]}`)
+		case "voice":
+			io.WriteString(w, `{"total_count":1,"item_count":1,"item":[{"media_id":"VOICE1","name":"voice","update_time":1}]}`)
+		case "video":
+			io.WriteString(w, `{"total_count":1,"item_count":1,"item":[{"media_id":"VIDEO1","name":"v.mp4","update_time":1}]}`)
+		case "news":
+			io.WriteString(w, `{"total_count":1,"item_count":1,"item":[{"media_id":"NEWS1","update_time":1,"content":{"news_item":[{"thumb_media_id":"IMG1","title":"T","content":"<p>hello</p>","show_cover_pic":1}]}}]}`)
+		default:
+			io.WriteString(w, `{"total_count":0,"item_count":0,"item":[]}`)
+		}
+	case "/cgi-bin/material/get_material":
+		var req struct {
+			MediaId string `json:"media_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.MediaId {
+		case "IMG1":
+			s.downloads++
+			io.WriteString(w, "image-data")
+		case "VOICE1":
+			s.downloads++
+			io.WriteString(w, "#!AMR\nvoice-data")
+		case "VIDEO1":
+			io.WriteString(w, `{"title":"标题","description":"描述","down_url":"http://video/VIDEO1"}`)
+		}
+	case "/VIDEO1":
+		s.downloads++
+		io.WriteString(w, "video-data")
+	case "/cgi-bin/material/add_material":
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			data, _ := ioutil.ReadAll(part)
+			if part.FormName() == "media" {
+				s.uploads = append(s.uploads, part.FileName()+"="+string(data))
+			}
+		}
+		io.WriteString(w, `{"media_id":"NEW_`+r.URL.Query().Get("type")+`","url":"http://new"}`)
+	case "/cgi-bin/draft/add":
+		data, _ := ioutil.ReadAll(r.Body)
+		s.news = append(s.news, string(data))
+		io.WriteString(w, `{"media_id":"NEW_NEWS"}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	fake := &fakeServer{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	clt := testutil.NewClient(srv.URL)
+
+	dir := t.TempDir()
+	manifest, err := Backup(clt, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Images) != 1 || len(manifest.Videos) != 1 || len(manifest.News) != 1 || len(manifest.Voices) != 1 {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	if manifest.Videos[0].Title != "标题" || manifest.Videos[0].File != "video/VIDEO1.mp4" {
+		t.Errorf("unexpected video: %+v", manifest.Videos[0])
+	}
+	if manifest.Voices[0].File != "voice/VOICE1.amr" {
+		t.Errorf("unexpected voice: %+v", manifest.Voices[0])
+	}
+	if fake.downloads != 3 {
+		t.Errorf("have %d downloads, want 3", fake.downloads)
+	}
+
+	// 再次备份, 没有变化的素材不会重新下载
+	if _, err = Backup(clt, dir); err != nil {
+		t.Fatal(err)
+	}
+	if fake.downloads != 3 {
+		t.Errorf("have %d downloads after second backup, want 3", fake.downloads)
+	}
+
+	result, err := Restore(clt, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaIdMap := result.MediaIdMap
+	if mediaIdMap["IMG1"] != "NEW_image" || mediaIdMap["VOICE1"] != "NEW_voice" || mediaIdMap["VIDEO1"] != "NEW_video" || mediaIdMap["NEWS1"] != "NEW_NEWS" {
+		t.Errorf("unexpected mediaIdMap: %v", mediaIdMap)
+	}
+	if have, want := strings.Join(fake.uploads, ","), "a.jpg=image-data,VOICE1.amr=#!AMR\nvoice-data,v.mp4=video-data"; have != want {
+		t.Errorf("have uploads %q, want %q", have, want)
+	}
+	if len(fake.news) != 1 || !strings.Contains(fake.news[0], `"thumb_media_id":"NEW_image"`) || !strings.Contains(fake.news[0], `<p>hello</p>`) {
+		t.Errorf("unexpected news: %v", fake.news)
+	}
+	if want := []string{"NEWS1/0"}; !reflect.DeepEqual(result.CoverPicDropped, want) {
+		t.Errorf("CoverPicDropped = %v, want %v", result.CoverPicDropped, want)
+	}
+}
@@ -0,0 +1,92 @@
+package backup
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ManifestFileName 是备份目录中清单文件的名称.
+const ManifestFileName = "manifest.json"
+
+// Manifest 是备份的清单, 以 JSON 格式保存在备份目录的 ManifestFileName 文件中.
+//
+//	备份目录的结构:
+//	manifest.json
+//	image/{media_id}{ext}
+//	voice/{media_id}{ext}
+//	video/{media_id}.mp4
+//	news/{media_id}/{index}.html  图文消息每篇文章的正文
+type Manifest struct {
+	Images []Item `json:"image"`
+	Voices []Item `json:"voice"`
+	Videos []Item `json:"video"`
+	News   []News `json:"news"`
+}
+
+// Item 是一个图片, 语音或者视频素材.
+type Item struct {
+	MediaId    string `json:"media_id"`
+	Name       string `json:"name"`
+	UpdateTime int64  `json:"update_time"`
+	URL        string `json:"url,omitempty"`         // 图片素材的URL
+	Title      string `json:"title,omitempty"`       // 视频素材的标题
+	Desc       string `json:"description,omitempty"` // 视频素材的描述
+	File       string `json:"file"`                  // 相对于备份目录的路径
+}
+
+// News 是一个图文素材.
+type News struct {
+	MediaId    string    `json:"media_id"`
+	UpdateTime int64     `json:"update_time"`
+	Articles   []Article `json:"articles"`
+}
+
+// Article 是图文素材中的一篇文章, 正文保存在 ContentFile 文件中.
+type Article struct {
+	ThumbMediaId     string `json:"thumb_media_id"`
+	Title            string `json:"title"`
+	Author           string `json:"author,omitempty"`
+	Digest           string `json:"digest,omitempty"`
+	ContentFile      string `json:"content_file"` // 相对于备份目录的路径
+	ContentSourceURL string `json:"content_source_url,omitempty"`
+	ShowCoverPic     int    `json:"show_cover_pic"`
+	URL              string `json:"url,omitempty"`
+}
+
+// LoadManifest 读取 dir 目录中的清单文件.
+func LoadManifest(dir string) (manifest *Manifest, err error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		return
+	}
+	var m Manifest
+	if err = json.Unmarshal(data, &m); err != nil {
+		return
+	}
+	manifest = &m
+	return
+}
+
+// save 先写入临时文件再重命名, 保证清单文件的完整.
+func (manifest *Manifest) save(dir string) (err error) {
+	data, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return
+	}
+	file, err := ioutil.TempFile(dir, ManifestFileName+".tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		return
+	}
+	if err = file.Close(); err != nil {
+		return
+	}
+	return os.Rename(file.Name(), filepath.Join(dir, ManifestFileName))
+}
@@ -0,0 +1,129 @@
+package backup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/chanxuehong/wechat/mp/core"
+	"github.com/chanxuehong/wechat/mp/draft"
+	"github.com/chanxuehong/wechat/mp/material"
+)
+
+// RestoreResult 是 Restore 的结果.
+type RestoreResult struct {
+	// MediaIdMap 是旧的素材id到新的素材id的映射, 图文素材对应新建的草稿的 media_id.
+	MediaIdMap map[string]string
+
+	// CoverPicDropped 是 show_cover_pic 为 1 的文章, 格式为 "{图文素材旧的 media_id}/{index}";
+	// 草稿没有 show_cover_pic 字段, 需要在正文中显示封面的话, 恢复之后自己修改草稿.
+	CoverPicDropped []string
+}
+
+// Restore 把 dir 目录中备份的素材上传到 clt 对应的公众号, 图文素材中的 thumb_media_id 会替换为新上传的素材id.
+//
+//	永久图文素材接口已经被草稿箱取代, 图文素材恢复为草稿, 需要的话再调用 freepublish.Submit 发布;
+//	遇到错误立即返回, 此时 result.MediaIdMap 包含已经上传成功的素材,
+//	可以根据 result.MediaIdMap 删除已经上传的素材或者从 manifest 中去掉已经上传的素材后重新恢复.
+func Restore(clt *core.Client, dir string) (result *RestoreResult, err error) {
+	manifest, err := LoadManifest(dir)
+	if err != nil {
+		return
+	}
+	return RestoreManifest(clt, dir, manifest)
+}
+
+// RestoreManifest 同 Restore, 但是使用指定的 manifest, 可以用来只恢复部分素材.
+func RestoreManifest(clt *core.Client, dir string, manifest *Manifest) (result *RestoreResult, err error) {
+	mediaIdMap := make(map[string]string)
+	result = &RestoreResult{MediaIdMap: mediaIdMap}
+
+	upload := func(item *Item, fn func(file *os.File) (string, error)) error {
+		file, err := os.Open(filepath.Join(dir, filepath.FromSlash(item.File)))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		mediaId, err := fn(file)
+		if err != nil {
+			return fmt.Errorf("restore %s failed: %s", item.File, err.Error())
+		}
+		mediaIdMap[item.MediaId] = mediaId
+		return nil
+	}
+
+	for i := range manifest.Images {
+		item := &manifest.Images[i]
+		err = upload(item, func(file *os.File) (mediaId string, err error) {
+			mediaId, _, err = material.UploadImageFromReader(clt, uploadName(item), file)
+			return
+		})
+		if err != nil {
+			return
+		}
+	}
+	for i := range manifest.Voices {
+		item := &manifest.Voices[i]
+		err = upload(item, func(file *os.File) (string, error) {
+			return material.UploadVoiceFromReader(clt, uploadName(item), file)
+		})
+		if err != nil {
+			return
+		}
+	}
+	for i := range manifest.Videos {
+		item := &manifest.Videos[i]
+		err = upload(item, func(file *os.File) (string, error) {
+			return material.UploadVideoFromReader(clt, uploadName(item), file, item.Title, item.Desc)
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	for i := range manifest.News {
+		src := &manifest.News[i]
+		articles := make([]draft.Article, len(src.Articles))
+		for j := range src.Articles {
+			article := &src.Articles[j]
+			thumbMediaId, ok := mediaIdMap[article.ThumbMediaId]
+			if !ok {
+				err = fmt.Errorf("restore news %s failed: thumb_media_id %s of article %d was not restored", src.MediaId, article.ThumbMediaId, j)
+				return
+			}
+			content, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(article.ContentFile)))
+			if err != nil {
+				return result, err
+			}
+			articles[j] = draft.Article{
+				ThumbMediaId:     thumbMediaId,
+				Title:            article.Title,
+				Author:           article.Author,
+				Digest:           article.Digest,
+				Content:          string(content),
+				ContentSourceURL: article.ContentSourceURL,
+			}
+		}
+		mediaId, err := draft.Add(clt, articles)
+		if err != nil {
+			return result, fmt.Errorf("restore news %s failed: %s", src.MediaId, err.Error())
+		}
+		mediaIdMap[src.MediaId] = mediaId
+		for j := range src.Articles {
+			if src.Articles[j].ShowCoverPic == 1 {
+				result.CoverPicDropped = append(result.CoverPicDropped, fmt.Sprintf("%s/%d", src.MediaId, j))
+			}
+		}
+	}
+	return
+}
+
+// uploadName 返回上传时 multipart/form-data 里 filename 的值, 微信根据扩展名判断文件类型.
+func uploadName(item *Item) string {
+	if filepath.Ext(item.Name) != "" {
+		return item.Name
+	}
+	return filepath.Base(item.File)
+}
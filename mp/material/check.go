@@ -0,0 +1,50 @@
+package material
+
+import (
+	"io"
+
+	"github.com/chanxuehong/wechat/mp/core"
+	"github.com/chanxuehong/wechat/mp/media"
+)
+
+// Uploader 在上传永久素材之前调用 media.PrepareUpload 检查(转换)文件, 不满足限制的文件不会上传.
+type Uploader struct {
+	Client     *core.Client
+	Transcoder media.Transcoder // 可选
+}
+
+// UploadImageFromReader 检查并上传多媒体图片
+func (u *Uploader) UploadImageFromReader(filename string, reader io.Reader) (mediaId, url string, err error) {
+	err = media.PrepareUpload(MaterialTypeImage, filename, reader, u.Transcoder, func(name string, r io.Reader) (err error) {
+		mediaId, url, err = UploadImageFromReader(u.Client, name, r)
+		return
+	})
+	return
+}
+
+// UploadThumbFromReader 检查并上传缩略图
+func (u *Uploader) UploadThumbFromReader(filename string, reader io.Reader) (mediaId, url string, err error) {
+	err = media.PrepareUpload(MaterialTypeThumb, filename, reader, u.Transcoder, func(name string, r io.Reader) (err error) {
+		mediaId, url, err = UploadThumbFromReader(u.Client, name, r)
+		return
+	})
+	return
+}
+
+// UploadVoiceFromReader 检查并上传多媒体语音
+func (u *Uploader) UploadVoiceFromReader(filename string, reader io.Reader) (mediaId string, err error) {
+	err = media.PrepareUpload(MaterialTypeVoice, filename, reader, u.Transcoder, func(name string, r io.Reader) (err error) {
+		mediaId, err = UploadVoiceFromReader(u.Client, name, r)
+		return
+	})
+	return
+}
+
+// UploadVideoFromReader 检查并上传多媒体视频
+func (u *Uploader) UploadVideoFromReader(filename string, reader io.Reader, title, introduction string) (mediaId string, err error) {
+	err = media.PrepareUpload(MaterialTypeVideo, filename, reader, u.Transcoder, func(name string, r io.Reader) (err error) {
+		mediaId, err = UploadVideoFromReader(u.Client, name, r, title, introduction)
+		return
+	})
+	return
+}
@@ -0,0 +1,61 @@
+package material
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+	"github.com/chanxuehong/wechat/mp/media"
+)
+
+func TestUploader(t *testing.T) {
+	var uploads []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, header, err := r.FormFile("media")
+		if err != nil {
+			t.Errorf("FormFile: %v", err)
+			return
+		}
+		uploads = append(uploads, r.URL.Query().Get("type")+":"+header.Filename)
+		fmt.Fprint(w, `{"errcode":0,"media_id":"MEDIA_ID","url":"URL"}`)
+	}))
+	defer srv.Close()
+
+	jpeg := append([]byte("\xFF\xD8\xFF\xE0"), make([]byte, 100)...)
+	big := append([]byte("\xFF\xD8\xFF\xE0"), make([]byte, media.MaxThumbSize)...)
+
+	// 没有 Transcoder, 超过限制的缩略图不上传
+	u := &Uploader{Client: testutil.NewClient(srv.URL)}
+	if _, _, err := u.UploadThumbFromReader("a.jpg", bytes.NewReader(big)); err == nil {
+		t.Error("want error for big thumb")
+	} else if _, ok := err.(*media.SizeError); !ok {
+		t.Errorf("want *media.SizeError, have %#v", err)
+	}
+	if len(uploads) != 0 {
+		t.Fatalf("unexpected uploads: %v", uploads)
+	}
+
+	// Transcoder 压缩之后上传, 扩展名根据内容修正
+	var reasons []error
+	u.Transcoder = media.TranscoderFunc(func(mediaType string, data []byte, reason error) ([]byte, error) {
+		reasons = append(reasons, reason)
+		return jpeg, nil
+	})
+	mediaId, url, err := u.UploadThumbFromReader("a.png", bytes.NewReader(big))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaId != "MEDIA_ID" || url != "URL" || len(reasons) != 1 {
+		t.Errorf("mediaId = %q, url = %q, reasons = %v", mediaId, url, reasons)
+	}
+	if _, _, err = u.UploadImageFromReader("b", bytes.NewReader(jpeg)); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"thumb:a.jpg", "image:b.jpg"}
+	if fmt.Sprint(uploads) != fmt.Sprint(want) {
+		t.Errorf("uploads = %v, want %v", uploads, want)
+	}
+}
@@ -22,6 +22,8 @@ type News struct {
 }
 
 // 新增永久图文素材.
+//
+// Deprecated: 微信已经用草稿箱和发布能力取代了该接口, 请使用 draft.Add 和 freepublish.Submit.
 func AddNews(clt *core.Client, news *News) (mediaId string, err error) {
 	const incompleteURL = "https://api.weixin.qq.com/cgi-bin/material/add_news?access_token="
 
@@ -67,6 +69,8 @@ func GetNews(clt *core.Client, mediaId string) (news *News, err error) {
 }
 
 // 修改永久图文素材.
+//
+// Deprecated: 微信已经用草稿箱和发布能力取代了该接口, 请使用 draft.Update.
 func UpdateNews(clt *core.Client, mediaId string, index int, article *Article) (err error) {
 	const incompleteURL = "https://api.weixin.qq.com/cgi-bin/material/update_news?access_token="
 
@@ -0,0 +1,419 @@
+// 新建草稿或者新增永久图文素材之前处理图文消息的 HTML 内容.
+//
+//	微信会去掉图文消息内容里的 JS 和外部图片, 或者直接拒绝. Sanitizer 把外部图片通过 base.UploadImage 上传到微信服务器
+//	并且替换 src, 删除不支持的标签和属性, 检查 2万字符/1M 的限制, 并且通过 Report 报告做了哪些修改.
+package sanitize
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/chanxuehong/wechat/mp/base"
+	"github.com/chanxuehong/wechat/mp/core"
+	"github.com/chanxuehong/wechat/mp/draft"
+	"github.com/chanxuehong/wechat/mp/material"
+	"github.com/chanxuehong/wechat/mp/media"
+	"github.com/chanxuehong/wechat/util"
+)
+
+// 图文消息内容的限制.
+const (
+	MaxContentLength = 20000   // 图文消息的内容必须少于2万字符
+	MaxContentSize   = 1 << 20 // 图文消息的内容必须小于1M
+	MaxImageSize     = 1 << 20 // 图文消息内的图片必须在1MB以下, 仅支持jpg/png格式
+)
+
+// 删除标签以及标签的内容.
+var removeWithContentTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "title": true, "head": true,
+	"iframe": true, "frame": true, "frameset": true, "object": true, "embed": true, "applet": true,
+	"form": true, "textarea": true, "select": true, "button": true, "svg": true, "math": true,
+}
+
+// 内容不是 HTML 的标签.
+var rawTextTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "textarea": true, "title": true, "xmp": true,
+}
+
+// 允许的标签, 其他的标签只删除标签本身, 保留标签的内容.
+var allowedTags = map[string]bool{
+	"p": true, "br": true, "hr": true, "span": true, "div": true, "section": true, "blockquote": true, "pre": true, "code": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"strong": true, "b": true, "em": true, "i": true, "u": true, "s": true, "strike": true, "del": true, "ins": true,
+	"sub": true, "sup": true, "small": true, "big": true, "font": true, "center": true, "mark": true, "q": true, "cite": true, "abbr": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"table": true, "caption": true, "colgroup": true, "col": true, "thead": true, "tbody": true, "tfoot": true, "tr": true, "th": true, "td": true,
+	"figure": true, "figcaption": true, "img": true, "a": true,
+}
+
+var voidTags = map[string]bool{
+	"br": true, "hr": true, "img": true, "col": true, "wbr": true,
+}
+
+// 所有允许的标签都可以使用的属性.
+var globalAttrs = map[string]bool{
+	"style": true, "class": true, "title": true, "align": true, "valign": true, "width": true, "height": true, "dir": true, "lang": true,
+}
+
+var tagAttrs = map[string]map[string]bool{
+	"a":     {"href": true, "target": true},
+	"img":   {"src": true, "alt": true},
+	"font":  {"color": true, "face": true, "size": true},
+	"table": {"border": true, "cellpadding": true, "cellspacing": true},
+	"td":    {"colspan": true, "rowspan": true},
+	"th":    {"colspan": true, "rowspan": true},
+	"col":   {"span": true},
+	"ol":    {"start": true, "type": true},
+}
+
+// ContentTooLongError 表示处理之后的内容仍然超过了 MaxContentLength 或者 MaxContentSize 的限制.
+type ContentTooLongError struct {
+	Length int // 字符数
+	Size   int // 字节数
+}
+
+func (e *ContentTooLongError) Error() string {
+	return fmt.Sprintf("content too long: %d characters (must be less than %d), %d bytes (must be less than %d)",
+		e.Length, MaxContentLength, e.Size, MaxContentSize)
+}
+
+// ImageError 表示下载或者上传图片失败.
+type ImageError struct {
+	Src string
+	Err error
+}
+
+func (e *ImageError) Error() string {
+	return "image " + e.Src + ": " + e.Err.Error()
+}
+
+// Report 记录 Sanitize 对内容做的修改.
+type Report struct {
+	RemovedTags     map[string]int    // 删除的标签 -> 次数, 包括连同内容一起删除的标签
+	RemovedAttrs    map[string]int    // 删除的属性("标签名.属性名") -> 次数
+	RemovedComments int               // 删除的注释的个数
+	RemovedImages   []string          // 因为 src 不是 http(s) 的地址而删除的图片
+	Images          map[string]string // 重新上传的图片, 原来的 src -> 上传之后的 url
+}
+
+func newReport() *Report {
+	return &Report{
+		RemovedTags:  make(map[string]int),
+		RemovedAttrs: make(map[string]int),
+		Images:       make(map[string]string),
+	}
+}
+
+// Changed 返回内容是否被修改了.
+func (r *Report) Changed() bool {
+	return len(r.RemovedTags) > 0 || len(r.RemovedAttrs) > 0 || r.RemovedComments > 0 ||
+		len(r.RemovedImages) > 0 || len(r.Images) > 0
+}
+
+// Sanitizer 处理图文消息的内容, 可以并发使用.
+type Sanitizer struct {
+	Client *core.Client // 用于上传图片
+
+	// HttpClient 用于下载外部图片, 如果为 nil 则默认用 util.DefaultMediaHttpClient.
+	//
+	// NOTE: 图片地址来自图文消息的内容, 下载的时候不会过滤 host, 内网地址(127.0.0.1, 10.x.x.x, 云服务器的元数据地址等)也会请求;
+	// 如果内容来自不可信的用户, 请设置一个受限制的 http.Client, 比如通过 Transport 的 DialContext 拒绝连接内网地址.
+	HttpClient *http.Client
+
+	mu     sync.Mutex
+	images map[string]string // 已经上传的图片, 原来的 src -> 上传之后的 url
+}
+
+func NewSanitizer(clt *core.Client) *Sanitizer {
+	return &Sanitizer{
+		Client: clt,
+	}
+}
+
+// Sanitize 处理图文消息的内容 content, 返回处理之后的内容.
+//
+//	如果处理之后的内容仍然超过限制, 返回处理之后的内容和 *ContentTooLongError;
+//	如果下载或者上传图片失败, 返回 *ImageError.
+func (s *Sanitizer) Sanitize(content string) (result string, report *Report, err error) {
+	report = newReport()
+
+	var buf strings.Builder
+	buf.Grow(len(content))
+
+	var (
+		skipTag   string // 正在删除的标签
+		skipDepth int
+	)
+	z := newTokenizer(content)
+	for tok, ok := z.next(); ok; tok, ok = z.next() {
+		if skipTag != "" {
+			switch {
+			case tok.Type == startTagToken && tok.Data == skipTag:
+				skipDepth++
+			case tok.Type == endTagToken && tok.Data == skipTag:
+				if skipDepth--; skipDepth == 0 {
+					skipTag = ""
+				}
+			}
+			continue
+		}
+
+		switch tok.Type {
+		case textToken:
+			buf.WriteString(strings.Replace(tok.Data, "<", "&lt;", -1))
+		case commentToken:
+			report.RemovedComments++
+		case doctypeToken, invalidToken:
+			report.RemovedTags[tok.Data]++
+		case endTagToken:
+			if allowedTags[tok.Data] && !voidTags[tok.Data] {
+				buf.WriteString("</" + tok.Data + ">")
+			}
+		case startTagToken, selfClosingTagToken:
+			name := tok.Data
+			switch {
+			case removeWithContentTags[name]:
+				report.RemovedTags[name]++
+				if tok.Type == startTagToken {
+					if rawTextTags[name] {
+						z.rawText(name)
+					} else {
+						skipTag, skipDepth = name, 1
+					}
+				}
+				continue
+			case !allowedTags[name]:
+				report.RemovedTags[name]++
+				continue
+			}
+
+			attrs := s.filterAttrs(name, tok.Attrs, report)
+			if name == "img" {
+				var keep bool
+				if attrs, keep, err = s.rehostImage(attrs, report); err != nil {
+					return
+				}
+				if !keep {
+					continue
+				}
+			}
+			buf.WriteString("<" + name)
+			for _, attr := range attrs {
+				buf.WriteString(" " + attr.Name + `="` + html.EscapeString(attr.Value) + `"`)
+			}
+			buf.WriteString(">")
+		}
+	}
+
+	result = buf.String()
+	if length := utf8.RuneCountInString(result); length >= MaxContentLength || len(result) >= MaxContentSize {
+		err = &ContentTooLongError{Length: length, Size: len(result)}
+	}
+	return
+}
+
+func (s *Sanitizer) filterAttrs(tag string, attrs []attribute, report *Report) []attribute {
+	filtered := attrs[:0]
+	for _, attr := range attrs {
+		if allowAttr(tag, attr) {
+			filtered = append(filtered, attr)
+			continue
+		}
+		// 微信编辑器导出的图片地址在 data-src 里
+		if tag == "img" && attr.Name == "data-src" && !hasAttr(attrs, "src") {
+			filtered = append(filtered, attribute{Name: "src", Value: attr.Value})
+			continue
+		}
+		report.RemovedAttrs[tag+"."+attr.Name]++
+	}
+	return filtered
+}
+
+func allowAttr(tag string, attr attribute) bool {
+	if !globalAttrs[attr.Name] && !tagAttrs[tag][attr.Name] {
+		return false
+	}
+	value := strings.ToLower(attr.Value)
+	switch attr.Name {
+	case "href":
+		return strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") || strings.HasPrefix(value, "#")
+	case "style":
+		return !strings.Contains(value, "expression(") && !strings.Contains(value, "javascript:") && !strings.Contains(value, "url(")
+	}
+	return true
+}
+
+func hasAttr(attrs []attribute, name string) bool {
+	for _, attr := range attrs {
+		if attr.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// rehostImage 把外部图片上传到微信服务器并替换 src, src 不是 http(s) 地址或者 data URI 的图片返回 keep == false.
+func (s *Sanitizer) rehostImage(attrs []attribute, report *Report) (_ []attribute, keep bool, err error) {
+	for i := range attrs {
+		if attrs[i].Name != "src" {
+			continue
+		}
+		src := attrs[i].Value
+		switch {
+		case isWeixinImage(src):
+			return attrs, true, nil
+		case strings.HasPrefix(src, "data:"), strings.HasPrefix(src, "http://"), strings.HasPrefix(src, "https://"), strings.HasPrefix(src, "//"):
+			newSrc, err := s.uploadImage(src)
+			if err != nil {
+				return nil, false, &ImageError{Src: src, Err: err}
+			}
+			report.Images[src] = newSrc
+			attrs[i].Value = newSrc
+			return attrs, true, nil
+		}
+		report.RemovedImages = append(report.RemovedImages, src)
+		return nil, false, nil
+	}
+	report.RemovedImages = append(report.RemovedImages, "")
+	return nil, false, nil
+}
+
+// isWeixinImage 返回 src 是否是微信服务器上的图片.
+func isWeixinImage(src string) bool {
+	u, err := url.Parse(src)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return strings.HasSuffix(host, ".qpic.cn") || strings.HasSuffix(host, ".qlogo.cn")
+}
+
+func (s *Sanitizer) uploadImage(src string) (newSrc string, err error) {
+	s.mu.Lock()
+	newSrc = s.images[src]
+	s.mu.Unlock()
+	if newSrc != "" {
+		return
+	}
+
+	var data []byte
+	if strings.HasPrefix(src, "data:") {
+		data, err = decodeDataURI(src)
+	} else {
+		data, err = s.download(src)
+	}
+	if err != nil {
+		return
+	}
+	if len(data) >= MaxImageSize {
+		return "", fmt.Errorf("image too large: %d bytes, must be less than %d bytes", len(data), MaxImageSize)
+	}
+	var filename string
+	switch format := media.DetectFormat(data); format {
+	case media.FormatJPEG:
+		filename = "image.jpg"
+	case media.FormatPNG:
+		filename = "image.png"
+	default:
+		return "", fmt.Errorf("unsupported image format %q, want jpg/png", format)
+	}
+	if newSrc, err = base.UploadImageFromReader(s.Client, filename, bytes.NewReader(data)); err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.images == nil {
+		s.images = make(map[string]string)
+	}
+	s.images[src] = newSrc
+	s.mu.Unlock()
+	return
+}
+
+func (s *Sanitizer) download(src string) ([]byte, error) {
+	if strings.HasPrefix(src, "//") {
+		src = "https:" + src
+	}
+	httpClient := s.HttpClient
+	if httpClient == nil {
+		httpClient = util.DefaultMediaHttpClient
+	}
+	httpResp, err := httpClient.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http.Status: %s", httpResp.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(httpResp.Body, MaxImageSize))
+}
+
+// decodeDataURI 解码 data:[<mediatype>][;base64],<data> 格式的图片.
+func decodeDataURI(src string) ([]byte, error) {
+	comma := strings.IndexByte(src, ',')
+	if comma < 0 {
+		return nil, fmt.Errorf("invalid data URI")
+	}
+	if !strings.HasSuffix(src[:comma], ";base64") {
+		return nil, fmt.Errorf("data URI is not base64 encoded")
+	}
+	return base64.StdEncoding.DecodeString(src[comma+1:])
+}
+
+// SanitizeNews 调用 Sanitize 处理 news 里全部图文消息的内容, 并且替换为处理之后的内容, reports[i] 对应 news.Articles[i].
+//
+//	NOTE: 遇到错误立即返回, 已经处理过的图文消息的内容已经被替换.
+func (s *Sanitizer) SanitizeNews(news *material.News) (reports []*Report, err error) {
+	contents := make([]*string, len(news.Articles))
+	for i := range news.Articles {
+		contents[i] = &news.Articles[i].Content
+	}
+	return s.sanitizeContents(contents)
+}
+
+// SanitizeDraft 调用 Sanitize 处理 articles 里全部图文消息的内容, 并且替换为处理之后的内容, reports[i] 对应 articles[i].
+//
+//	NOTE: 遇到错误立即返回, 已经处理过的图文消息的内容已经被替换.
+func (s *Sanitizer) SanitizeDraft(articles []draft.Article) (reports []*Report, err error) {
+	contents := make([]*string, len(articles))
+	for i := range articles {
+		contents[i] = &articles[i].Content
+	}
+	return s.sanitizeContents(contents)
+}
+
+// sanitizeContents 依次处理 *contents[i] 并且替换为处理之后的内容, 遇到错误立即返回.
+func (s *Sanitizer) sanitizeContents(contents []*string) (reports []*Report, err error) {
+	reports = make([]*Report, 0, len(contents))
+	for _, content := range contents {
+		result, report, err := s.Sanitize(*content)
+		if err != nil {
+			return reports, err
+		}
+		*content = result
+		reports = append(reports, report)
+	}
+	return
+}
+
+// AddDraft 调用 SanitizeDraft 处理图文消息的内容, 然后新建草稿.
+//
+//	永久图文素材接口(material.AddNews)已经被草稿箱取代, 所以这里只提供新建草稿.
+func (s *Sanitizer) AddDraft(articles []draft.Article) (mediaId string, reports []*Report, err error) {
+	if reports, err = s.SanitizeDraft(articles); err != nil {
+		return
+	}
+	mediaId, err = draft.Add(s.Client, articles)
+	return
+}
@@ -0,0 +1,139 @@
+package sanitize
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+	"github.com/chanxuehong/wechat/mp/draft"
+)
+
+const pngData = "\x89PNG\r\n\x1A\n\x00\x00\x00\x0DIHDR"
+
+func newTestSanitizer(t *testing.T) (s *Sanitizer, imageURL string, uploads *int, closeFn func()) {
+	uploads = new(int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a.png":
+			w.Write([]byte(pngData))
+		case "/a.gif":
+			w.Write([]byte("GIF89a\x01\x00"))
+		case "/cgi-bin/media/uploadimg":
+			*uploads++
+			fmt.Fprintf(w, `{"url":"http://mmbiz.qpic.cn/mmbiz/%d"}`, *uploads)
+		case "/cgi-bin/draft/add":
+			var req struct {
+				Articles []draft.Article `json:"articles"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			if len(req.Articles) != 1 || req.Articles[0].Content != `<p>ok</p>` {
+				t.Errorf("unexpected draft: %+v", req.Articles)
+			}
+			io.WriteString(w, `{"media_id":"DRAFT"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	clt := testutil.NewClient(srv.URL)
+	s = NewSanitizer(clt)
+	s.HttpClient = srv.Client()
+	return s, srv.URL + "/a.png", uploads, srv.Close
+}
+
+func TestSanitize(t *testing.T) {
+	s, imageURL, uploads, closeFn := newTestSanitizer(t)
+	defer closeFn()
+
+	content := `<!DOCTYPE html><!-- comment --><p class="a" onclick="alert(1)">1 < 2 &amp; 3</p>` +
+		`<script type="text/javascript">document.write("<p>x</p>")</script>` +
+		`<iframe src="http://example.com"><iframe></iframe><p>inner</p></iframe>` +
+		`<mpvoice name=x>voice</mpvoice>` +
+		`<img src='` + imageURL + `' alt="a &quot;b&quot;"><img data-src="` + imageURL + `"/>` +
+		`<img src="http://mmbiz.qpic.cn/mmbiz/0"><img src="/relative.png">` +
+		`<a href="javascript:alert(1)" target=_blank>link</a><a href="https://mp.weixin.qq.com/s/x">ok</a>` +
+		`<span style="background:url(http://example.com/a.png)">s</span>`
+
+	result, report, err := s.Sanitize(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<p class="a">1 &lt; 2 &amp; 3</p>` +
+		`voice` +
+		`<img src="http://mmbiz.qpic.cn/mmbiz/1" alt="a &#34;b&#34;"><img src="http://mmbiz.qpic.cn/mmbiz/1">` +
+		`<img src="http://mmbiz.qpic.cn/mmbiz/0">` +
+		`<a target="_blank">link</a><a href="https://mp.weixin.qq.com/s/x">ok</a>` +
+		`<span>s</span>`
+	if result != want {
+		t.Errorf("result:\n%s\nwant:\n%s", result, want)
+	}
+	if *uploads != 1 {
+		t.Errorf("uploads = %d", *uploads)
+	}
+
+	wantReport := &Report{
+		RemovedTags:     map[string]int{"!doctype": 1, "script": 1, "iframe": 1, "mpvoice": 1},
+		RemovedAttrs:    map[string]int{"p.onclick": 1, "a.href": 1, "span.style": 1},
+		RemovedComments: 1,
+		RemovedImages:   []string{"/relative.png"},
+		Images:          map[string]string{imageURL: "http://mmbiz.qpic.cn/mmbiz/1"},
+	}
+	if !reflect.DeepEqual(report, wantReport) {
+		t.Errorf("report = %+v\nwant %+v", report, wantReport)
+	}
+	if !report.Changed() {
+		t.Error("Changed() = false")
+	}
+
+	// 已经上传过的图片不再上传
+	if _, report, err = s.Sanitize(`<p><img src="` + imageURL + `"></p>`); err != nil || *uploads != 1 {
+		t.Errorf("Sanitize = %v, uploads = %d", err, *uploads)
+	}
+	if _, report, _ = s.Sanitize(`<p>ok</p>`); report.Changed() {
+		t.Errorf("report = %+v", report)
+	}
+
+	dataURI := "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte(pngData))
+	if result, _, err = s.Sanitize(`<img src="` + dataURI + `">`); err != nil || result != `<img src="http://mmbiz.qpic.cn/mmbiz/2">` {
+		t.Errorf("Sanitize data URI = %q, %v", result, err)
+	}
+}
+
+func TestSanitizeErrors(t *testing.T) {
+	s, imageURL, _, closeFn := newTestSanitizer(t)
+	defer closeFn()
+
+	gifURL := strings.Replace(imageURL, "a.png", "a.gif", 1)
+	_, _, err := s.Sanitize(`<img src="` + gifURL + `">`)
+	if e, ok := err.(*ImageError); !ok || e.Src != gifURL {
+		t.Errorf("err = %#v", err)
+	}
+
+	result, _, err := s.Sanitize(strings.Repeat("中", MaxContentLength))
+	if e, ok := err.(*ContentTooLongError); !ok || e.Length != MaxContentLength || e.Size != 3*MaxContentLength {
+		t.Errorf("err = %#v", err)
+	}
+	if len(result) != 3*MaxContentLength {
+		t.Errorf("len(result) = %d", len(result))
+	}
+}
+
+func TestAddDraft(t *testing.T) {
+	s, _, _, closeFn := newTestSanitizer(t)
+	defer closeFn()
+
+	articles := []draft.Article{{Title: "T", ThumbMediaId: "THUMB", Content: `<p onclick="x()">ok</p>`}}
+	mediaId, reports, err := s.AddDraft(articles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaId != "DRAFT" || len(reports) != 1 || reports[0].RemovedAttrs["p.onclick"] != 1 || articles[0].Content != `<p>ok</p>` {
+		t.Errorf("mediaId = %q, reports = %+v, articles = %+v", mediaId, reports, articles)
+	}
+}
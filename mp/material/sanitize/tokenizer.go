@@ -0,0 +1,192 @@
+package sanitize
+
+import (
+	"html"
+	"strings"
+)
+
+type tokenType int
+
+const (
+	textToken tokenType = iota
+	startTagToken
+	endTagToken
+	selfClosingTagToken
+	commentToken // <!-- ... -->
+	doctypeToken // <!DOCTYPE ...>, <![CDATA[...]]> 等
+	invalidToken // 不完整的标签, 比如文档末尾的 "<p class="
+)
+
+type attribute struct {
+	Name  string // 小写
+	Value string // 已经解码了 HTML 实体
+}
+
+type token struct {
+	Type  tokenType
+	Data  string // textToken 为原始文本, 标签为小写的标签名
+	Attrs []attribute
+}
+
+// tokenizer 是一个简化的 HTML 词法分析器, 只处理图文消息内容里面常见的写法, 不构造 DOM 树.
+type tokenizer struct {
+	s   string
+	pos int
+}
+
+func newTokenizer(s string) *tokenizer {
+	return &tokenizer{s: s}
+}
+
+// next 返回下一个 token, 没有更多的 token 返回 false.
+func (z *tokenizer) next() (tok token, ok bool) {
+	if z.pos >= len(z.s) {
+		return
+	}
+	s := z.s[z.pos:]
+	if s[0] != '<' || len(s) == 1 {
+		return z.text(), true
+	}
+	switch c := s[1]; {
+	case strings.HasPrefix(s, "<!--"):
+		end := strings.Index(s[4:], "-->")
+		if end < 0 {
+			z.pos = len(z.s)
+		} else {
+			z.pos += 4 + end + 3
+		}
+		return token{Type: commentToken}, true
+	case c == '!' || c == '?':
+		z.skipPast('>')
+		return token{Type: doctypeToken, Data: "!doctype"}, true
+	case c == '/' && len(s) > 2 && isLetter(s[2]):
+		z.pos += 2
+		name := z.tagName()
+		if !z.skipPast('>') {
+			return token{Type: invalidToken, Data: name}, true
+		}
+		return token{Type: endTagToken, Data: name}, true
+	case isLetter(c):
+		z.pos++
+		return z.tag(), true
+	}
+	// 不是标签的 '<' 当作文本
+	return z.text(), true
+}
+
+// text 读取到下一个可能是标签开始的 '<' 为止的文本.
+func (z *tokenizer) text() token {
+	start := z.pos
+	i := strings.IndexByte(z.s[z.pos+1:], '<')
+	if i < 0 {
+		z.pos = len(z.s)
+	} else {
+		z.pos += 1 + i
+	}
+	return token{Type: textToken, Data: z.s[start:z.pos]}
+}
+
+func (z *tokenizer) tag() token {
+	tok := token{Type: startTagToken, Data: z.tagName()}
+	for {
+		z.skipSpace()
+		if z.pos >= len(z.s) {
+			return token{Type: invalidToken, Data: tok.Data}
+		}
+		switch z.s[z.pos] {
+		case '>':
+			z.pos++
+			return tok
+		case '/':
+			z.pos++
+			if z.pos < len(z.s) && z.s[z.pos] == '>' {
+				z.pos++
+				tok.Type = selfClosingTagToken
+				return tok
+			}
+			continue
+		}
+
+		start := z.pos
+		for z.pos < len(z.s) && !isSpace(z.s[z.pos]) && !strings.ContainsRune("=/>", rune(z.s[z.pos])) {
+			z.pos++
+		}
+		attr := attribute{Name: strings.ToLower(z.s[start:z.pos])}
+		z.skipSpace()
+		if z.pos < len(z.s) && z.s[z.pos] == '=' {
+			z.pos++
+			z.skipSpace()
+			attr.Value = html.UnescapeString(z.attrValue())
+		}
+		if attr.Name != "" {
+			tok.Attrs = append(tok.Attrs, attr)
+		}
+	}
+}
+
+func (z *tokenizer) tagName() string {
+	start := z.pos
+	for z.pos < len(z.s) && !isSpace(z.s[z.pos]) && z.s[z.pos] != '/' && z.s[z.pos] != '>' {
+		z.pos++
+	}
+	return strings.ToLower(z.s[start:z.pos])
+}
+
+func (z *tokenizer) attrValue() string {
+	if z.pos >= len(z.s) {
+		return ""
+	}
+	if quote := z.s[z.pos]; quote == '"' || quote == '\'' {
+		z.pos++
+		end := strings.IndexByte(z.s[z.pos:], quote)
+		if end < 0 {
+			value := z.s[z.pos:]
+			z.pos = len(z.s)
+			return value
+		}
+		value := z.s[z.pos : z.pos+end]
+		z.pos += end + 1
+		return value
+	}
+	start := z.pos
+	for z.pos < len(z.s) && !isSpace(z.s[z.pos]) && z.s[z.pos] != '>' {
+		z.pos++
+	}
+	return z.s[start:z.pos]
+}
+
+// rawText 跳过 script, style 等元素的内容和结束标签, 这些元素的内容不是 HTML.
+func (z *tokenizer) rawText(name string) {
+	end := strings.Index(strings.ToLower(z.s[z.pos:]), "</"+name)
+	if end < 0 {
+		z.pos = len(z.s)
+		return
+	}
+	z.pos += end
+	z.skipPast('>')
+}
+
+// skipPast 跳过到 c 之后, 没有找到 c 返回 false 并且跳到末尾.
+func (z *tokenizer) skipPast(c byte) bool {
+	i := strings.IndexByte(z.s[z.pos:], c)
+	if i < 0 {
+		z.pos = len(z.s)
+		return false
+	}
+	z.pos += i + 1
+	return true
+}
+
+func (z *tokenizer) skipSpace() {
+	for z.pos < len(z.s) && isSpace(z.s[z.pos]) {
+		z.pos++
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,355 @@
+package media
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+// MediaIdLifetime 临时素材的 media_id 的有效期.
+const MediaIdLifetime = 3 * 24 * time.Hour
+
+// DefaultCacheMargin 是 Cache.Margin 的默认值.
+const DefaultCacheMargin = time.Hour
+
+var timeNow = time.Now
+
+// CacheStore 保存 Cache 上传的临时素材, key 由素材类型和文件内容的 sha256 组成, 参考 CacheKey.
+type CacheStore interface {
+	// Load 返回 key 对应的素材, 没有记录则 ok 为 false.
+	Load(key string) (info MediaInfo, ok bool, err error)
+	// Save 保存 key 对应的素材.
+	Save(key string, info MediaInfo) error
+	// Delete 删除 keys 对应的素材, key 不存在不是错误.
+	Delete(keys ...string) error
+	// Range 依次对每一条记录调用 f, f 返回 false 则停止遍历; f 里面不能调用 CacheStore 的方法.
+	Range(f func(key string, info MediaInfo) bool) error
+}
+
+// Cache 是内容寻址的临时素材缓存.
+// 相同类型相同内容的文件在 media_id 有效期内只上传一次, 过期之后再次调用会重新上传,
+// 每次上传的时候顺便删除已经超过 MediaIdLifetime 的记录;
+// 一般用于客服消息等需要反复发送同一个图片(语音, 视频)的场景, 比如:
+//
+//	info, err := cache.Upload(media.MediaTypeImage, "/path/to/image.jpg")
+//	if err != nil {
+//		return err
+//	}
+//	err = custom.Send(clt, custom.NewImage(toUser, info.MediaId, ""))
+//
+//	NOTE: 并发上传同一个文件可能会上传多次, 不影响正确性.
+type Cache struct {
+	clt   *core.Client
+	store CacheStore
+
+	// Margin 提前多长时间认为 media_id 已经过期, 避免取出的 media_id 在使用的时候刚好过期, 默认为 DefaultCacheMargin.
+	Margin time.Duration
+}
+
+// NewCache 创建一个新的 Cache, 如果 store == nil 则默认使用 NewMemoryCacheStore().
+func NewCache(clt *core.Client, store CacheStore) *Cache {
+	if store == nil {
+		store = NewMemoryCacheStore()
+	}
+	return &Cache{
+		clt:   clt,
+		store: store,
+	}
+}
+
+// Upload 返回文件 _filepath 对应的临时素材, 缓存中没有或者已经过期则上传.
+func (c *Cache) Upload(mediaType, _filepath string) (info *MediaInfo, err error) {
+	file, err := os.Open(_filepath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return
+	}
+	key := cacheKey(mediaType, hash.Sum(nil))
+	if info, err = c.load(key); err != nil || info != nil {
+		return
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+	return c.upload(key, mediaType, filepath.Base(_filepath), file)
+}
+
+// UploadFromReader 返回 reader 的内容对应的临时素材, 缓存中没有或者已经过期则上传.
+//
+//	NOTE: 参数 filename 不是文件路径, 是 multipart/form-data 里面 filename 的值.
+func (c *Cache) UploadFromReader(mediaType, filename string, reader io.Reader) (info *MediaInfo, err error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(data)
+	key := cacheKey(mediaType, sum[:])
+	if info, err = c.load(key); err != nil || info != nil {
+		return
+	}
+	return c.upload(key, mediaType, filename, bytes.NewReader(data))
+}
+
+// CacheKey 返回内容为 data 的 mediaType 类型的素材在 CacheStore 中的 key.
+func CacheKey(mediaType string, data []byte) string {
+	sum := sha256.Sum256(data)
+	return cacheKey(mediaType, sum[:])
+}
+
+func cacheKey(mediaType string, sum []byte) string {
+	return mediaType + ":" + hex.EncodeToString(sum)
+}
+
+// load 返回 key 对应的仍然有效的素材, 没有或者已经过期返回 nil.
+func (c *Cache) load(key string) (*MediaInfo, error) {
+	info, ok, err := c.store.Load(key)
+	if err != nil || !ok {
+		return nil, err
+	}
+	margin := c.Margin
+	if margin <= 0 {
+		margin = DefaultCacheMargin
+	}
+	if expiresAt := time.Unix(info.CreatedAt, 0).Add(MediaIdLifetime - margin); !timeNow().Before(expiresAt) {
+		return nil, nil
+	}
+	return &info, nil
+}
+
+func (c *Cache) upload(key, mediaType, filename string, reader io.Reader) (info *MediaInfo, err error) {
+	switch mediaType {
+	case MediaTypeThumb:
+		info, err = UploadThumbFromReader(c.clt, filename, reader)
+	case MediaTypeImage, MediaTypeVoice, MediaTypeVideo:
+		info, err = uploadFromReader(c.clt, mediaType, filename, reader)
+	default:
+		err = fmt.Errorf("unsupported media type: %s", mediaType)
+	}
+	if err != nil {
+		return
+	}
+	if info.CreatedAt == 0 {
+		info.CreatedAt = timeNow().Unix()
+	}
+	if err = c.prune(); err != nil {
+		return
+	}
+	err = c.store.Save(key, *info)
+	return
+}
+
+// prune 删除已经超过 MediaIdLifetime 的记录.
+func (c *Cache) prune() error {
+	now := timeNow()
+	return c.removeFunc(func(info MediaInfo) bool {
+		return !now.Before(time.Unix(info.CreatedAt, 0).Add(MediaIdLifetime))
+	})
+}
+
+// removeFunc 删除 f 返回 true 的记录.
+func (c *Cache) removeFunc(f func(info MediaInfo) bool) error {
+	var keys []string
+	err := c.store.Range(func(key string, info MediaInfo) bool {
+		if f(info) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+	return c.store.Delete(keys...)
+}
+
+// Remove 删除文件内容为 data 的 mediaType 类型的素材的缓存, 一般在微信服务器返回 media_id 无效的时候调用.
+func (c *Cache) Remove(mediaType string, data []byte) error {
+	return c.store.Delete(CacheKey(mediaType, data))
+}
+
+// RemoveKey 删除 key 对应的素材的缓存, key 参考 CacheKey.
+func (c *Cache) RemoveKey(key string) error {
+	return c.store.Delete(key)
+}
+
+// RemoveMediaId 删除 media_id 为 mediaId 的素材的缓存, 不需要重新读取文件内容.
+func (c *Cache) RemoveMediaId(mediaId string) error {
+	return c.removeFunc(func(info MediaInfo) bool {
+		return info.MediaId == mediaId
+	})
+}
+
+// =====================================================================================================================
+
+var _ CacheStore = (*MemoryCacheStore)(nil)
+
+// MemoryCacheStore 是进程内的 CacheStore 实现.
+type MemoryCacheStore struct {
+	mu sync.RWMutex
+	m  map[string]MediaInfo
+}
+
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{
+		m: make(map[string]MediaInfo),
+	}
+}
+
+func (store *MemoryCacheStore) Load(key string) (info MediaInfo, ok bool, err error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	info, ok = store.m[key]
+	return
+}
+
+func (store *MemoryCacheStore) Save(key string, info MediaInfo) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.m[key] = info
+	return nil
+}
+
+func (store *MemoryCacheStore) Delete(keys ...string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for _, key := range keys {
+		delete(store.m, key)
+	}
+	return nil
+}
+
+func (store *MemoryCacheStore) Range(f func(key string, info MediaInfo) bool) error {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	for key, info := range store.m {
+		if !f(key, info) {
+			break
+		}
+	}
+	return nil
+}
+
+var _ CacheStore = (*FileCacheStore)(nil)
+
+// FileCacheStore 把全部记录以 JSON 格式保存在一个文件中, 进程重启之后仍然可以复用没有过期的 media_id.
+// 文件只在第一次访问的时候读取, 之后的读操作都在内存中完成, 写操作同时更新内存和文件.
+//
+//	NOTE: 只能在单个进程中使用.
+type FileCacheStore struct {
+	mu   sync.Mutex
+	path string
+	m    map[string]MediaInfo // 文件内容的缓存, nil 表示还没有读取
+}
+
+func NewFileCacheStore(path string) *FileCacheStore {
+	return &FileCacheStore{path: path}
+}
+
+func (store *FileCacheStore) Load(key string) (info MediaInfo, ok bool, err error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if err = store.read(); err != nil {
+		return
+	}
+	info, ok = store.m[key]
+	return
+}
+
+func (store *FileCacheStore) Save(key string, info MediaInfo) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if err := store.read(); err != nil {
+		return err
+	}
+	store.m[key] = info
+	return store.write()
+}
+
+func (store *FileCacheStore) Delete(keys ...string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if err := store.read(); err != nil {
+		return err
+	}
+	n := len(store.m)
+	for _, key := range keys {
+		delete(store.m, key)
+	}
+	if len(store.m) == n {
+		return nil
+	}
+	return store.write()
+}
+
+func (store *FileCacheStore) Range(f func(key string, info MediaInfo) bool) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if err := store.read(); err != nil {
+		return err
+	}
+	for key, info := range store.m {
+		if !f(key, info) {
+			break
+		}
+	}
+	return nil
+}
+
+// read 在第一次访问的时候读取文件到 store.m.
+func (store *FileCacheStore) read() error {
+	if store.m != nil {
+		return nil
+	}
+	m := make(map[string]MediaInfo)
+	b, err := ioutil.ReadFile(store.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			store.m = m
+			return nil
+		}
+		return err
+	}
+	if err = json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	store.m = m
+	return nil
+}
+
+// write 把 store.m 写入文件, 先写临时文件再重命名, 避免写到一半的文件;
+// 写入失败则丢弃内存中的修改, 下次访问的时候重新读取文件.
+func (store *FileCacheStore) write() (err error) {
+	defer func() {
+		if err != nil {
+			store.m = nil
+		}
+	}()
+
+	b, err := json.Marshal(store.m)
+	if err != nil {
+		return err
+	}
+	tmp := store.path + ".tmp"
+	if err = ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, store.path)
+}
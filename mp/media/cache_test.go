@@ -0,0 +1,162 @@
+package media
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	uploads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploads++
+		mediaType := r.URL.Query().Get("type")
+		if mediaType == MediaTypeThumb {
+			fmt.Fprintf(w, `{"type":"thumb","thumb_media_id":"thumb%d","created_at":%d}`, uploads, now.Unix())
+			return
+		}
+		fmt.Fprintf(w, `{"type":%q,"media_id":"media%d","created_at":%d}`, mediaType, uploads, now.Unix())
+	}))
+	defer srv.Close()
+	clt := testutil.NewClient(srv.URL)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.jpg")
+	if err := ioutil.WriteFile(path, []byte("image content"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewCache(clt, NewFileCacheStore(filepath.Join(dir, "cache.json")))
+	info, err := cache.Upload(MediaTypeImage, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.MediaId != "media1" {
+		t.Fatalf("MediaId = %q", info.MediaId)
+	}
+
+	// 相同内容复用 media_id, 不同类型重新上传
+	if info, err = cache.UploadFromReader(MediaTypeImage, "b.jpg", strings.NewReader("image content")); err != nil || info.MediaId != "media1" {
+		t.Fatalf("UploadFromReader = %+v, %v", info, err)
+	}
+	if info, err = cache.UploadFromReader(MediaTypeThumb, "b.jpg", strings.NewReader("image content")); err != nil || info.MediaId != "thumb2" {
+		t.Fatalf("UploadFromReader thumb = %+v, %v", info, err)
+	}
+
+	// 重新打开的 store 仍然可以复用
+	cache = NewCache(clt, NewFileCacheStore(filepath.Join(dir, "cache.json")))
+	now = now.Add(MediaIdLifetime - DefaultCacheMargin - time.Second)
+	if info, err = cache.Upload(MediaTypeImage, path); err != nil || info.MediaId != "media1" {
+		t.Fatalf("Upload = %+v, %v", info, err)
+	}
+
+	// 过期之后重新上传
+	now = now.Add(time.Second)
+	if info, err = cache.Upload(MediaTypeImage, path); err != nil || info.MediaId != "media3" {
+		t.Fatalf("Upload after expiry = %+v, %v", info, err)
+	}
+
+	if err = cache.Remove(MediaTypeImage, []byte("image content")); err != nil {
+		t.Fatal(err)
+	}
+	if info, err = cache.Upload(MediaTypeImage, path); err != nil || info.MediaId != "media4" {
+		t.Fatalf("Upload after Remove = %+v, %v", info, err)
+	}
+	if err = cache.RemoveMediaId("media4"); err != nil {
+		t.Fatal(err)
+	}
+	if info, err = cache.Upload(MediaTypeImage, path); err != nil || info.MediaId != "media5" {
+		t.Fatalf("Upload after RemoveMediaId = %+v, %v", info, err)
+	}
+	if err = cache.RemoveKey(CacheKey(MediaTypeImage, []byte("image content"))); err != nil {
+		t.Fatal(err)
+	}
+	if info, err = cache.Upload(MediaTypeImage, path); err != nil || info.MediaId != "media6" {
+		t.Fatalf("Upload after RemoveKey = %+v, %v", info, err)
+	}
+	if uploads != 6 {
+		t.Errorf("uploads = %d", uploads)
+	}
+}
+
+func TestCachePrune(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	uploads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploads++
+		fmt.Fprintf(w, `{"type":"image","media_id":"media%d","created_at":%d}`, uploads, now.Unix())
+	}))
+	defer srv.Close()
+
+	store := NewMemoryCacheStore()
+	cache := NewCache(testutil.NewClient(srv.URL), store)
+	if _, err := cache.UploadFromReader(MediaTypeImage, "a.jpg", strings.NewReader("a")); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Hour)
+	if _, err := cache.UploadFromReader(MediaTypeImage, "b.jpg", strings.NewReader("b")); err != nil {
+		t.Fatal(err)
+	}
+
+	// a 已经超过 MediaIdLifetime, 上传 c 的时候删除; b 还没有过期, 保留
+	now = now.Add(MediaIdLifetime - time.Hour)
+	if _, err := cache.UploadFromReader(MediaTypeImage, "c.jpg", strings.NewReader("c")); err != nil {
+		t.Fatal(err)
+	}
+	var mediaIds []string
+	store.Range(func(key string, info MediaInfo) bool {
+		mediaIds = append(mediaIds, info.MediaId)
+		return true
+	})
+	sort.Strings(mediaIds)
+	if want := []string{"media2", "media3"}; !reflect.DeepEqual(mediaIds, want) {
+		t.Errorf("mediaIds = %v, want %v", mediaIds, want)
+	}
+}
+
+func TestFileCacheStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	store := NewFileCacheStore(path)
+	if err := store.Save("a", MediaInfo{MediaId: "A"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save("b", MediaInfo{MediaId: "B"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 读取之后不再访问文件
+	if err := ioutil.WriteFile(path, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if info, ok, err := store.Load("a"); err != nil || !ok || info.MediaId != "A" {
+		t.Fatalf("Load = %+v, %v, %v", info, ok, err)
+	}
+
+	// 写操作把内存中的全部记录写回文件
+	if err := store.Delete("a", "c"); err != nil {
+		t.Fatal(err)
+	}
+	store = NewFileCacheStore(path)
+	if _, ok, _ := store.Load("a"); ok {
+		t.Error("a should be deleted")
+	}
+	if info, ok, err := store.Load("b"); err != nil || !ok || info.MediaId != "B" {
+		t.Fatalf("Load = %+v, %v, %v", info, ok, err)
+	}
+}
@@ -0,0 +1,290 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 上传临时素材的限制.
+const (
+	MaxImageSize     = 10 << 20         // 图片(image): 10MB, 支持bmp/png/jpeg/jpg/gif格式
+	MaxVoiceSize     = 2 << 20          // 语音(voice): 2MB, 播放长度不超过60s, 支持amr/mp3格式
+	MaxVoiceDuration = 60 * time.Second // 语音(voice): 播放长度不超过60s
+	MaxVideoSize     = 10 << 20         // 视频(video): 10MB, 支持mp4格式
+	MaxThumbSize     = 64 << 10         // 缩略图(thumb): 64KB, 支持jpg格式
+)
+
+// 根据文件内容识别的格式.
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatBMP  = "bmp"
+	FormatAMR  = "amr"
+	FormatMP3  = "mp3"
+	FormatMP4  = "mp4"
+)
+
+type limit struct {
+	maxSize     int64
+	formats     []string
+	maxDuration time.Duration
+}
+
+var limits = map[string]limit{
+	MediaTypeImage: {MaxImageSize, []string{FormatBMP, FormatPNG, FormatJPEG, FormatGIF}, 0},
+	MediaTypeVoice: {MaxVoiceSize, []string{FormatAMR, FormatMP3}, MaxVoiceDuration},
+	MediaTypeVideo: {MaxVideoSize, []string{FormatMP4}, 0},
+	MediaTypeThumb: {MaxThumbSize, []string{FormatJPEG}, 0},
+}
+
+// SizeError 表示文件太大.
+type SizeError struct {
+	MediaType string
+	Size      int64
+	MaxSize   int64
+}
+
+func (e *SizeError) Error() string {
+	return fmt.Sprintf("%s too large: %d bytes, max %d bytes", e.MediaType, e.Size, e.MaxSize)
+}
+
+// FormatError 表示文件格式不支持, Format 为空表示不能识别文件的格式.
+type FormatError struct {
+	MediaType string
+	Format    string
+	Formats   []string // 支持的格式
+}
+
+func (e *FormatError) Error() string {
+	format := e.Format
+	if format == "" {
+		format = "unknown"
+	}
+	return fmt.Sprintf("unsupported %s format: %s, want %s", e.MediaType, format, strings.Join(e.Formats, "/"))
+}
+
+// DurationError 表示语音的播放长度太长.
+type DurationError struct {
+	MediaType   string
+	Duration    time.Duration
+	MaxDuration time.Duration
+}
+
+func (e *DurationError) Error() string {
+	return fmt.Sprintf("%s too long: %s, max %s", e.MediaType, e.Duration, e.MaxDuration)
+}
+
+// Check 在上传之前根据文件内容检查文件是否满足 mediaType 类型的素材的限制(大小, 格式, 语音的播放长度),
+// 不满足的返回 *SizeError, *FormatError 或 *DurationError.
+func Check(mediaType string, data []byte) error {
+	l, ok := limits[mediaType]
+	if !ok {
+		return fmt.Errorf("unsupported media type: %s", mediaType)
+	}
+	format := DetectFormat(data)
+	if !containsString(l.formats, format) {
+		return &FormatError{MediaType: mediaType, Format: format, Formats: l.formats}
+	}
+	if size := int64(len(data)); size > l.maxSize {
+		return &SizeError{MediaType: mediaType, Size: size, MaxSize: l.maxSize}
+	}
+	if l.maxDuration > 0 {
+		var duration time.Duration
+		switch format {
+		case FormatAMR:
+			duration = amrDuration(data)
+		case FormatMP3:
+			duration = mp3Duration(data)
+		}
+		if duration > l.maxDuration {
+			return &DurationError{MediaType: mediaType, Duration: duration, MaxDuration: l.maxDuration}
+		}
+	}
+	return nil
+}
+
+// DetectFormat 根据文件头识别文件格式, 不能识别返回 "".
+func DetectFormat(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\xFF\xD8\xFF")):
+		return FormatJPEG
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1A\n")):
+		return FormatPNG
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return FormatGIF
+	case isBMP(data):
+		return FormatBMP
+	case bytes.HasPrefix(data, []byte("#!AMR")):
+		return FormatAMR
+	case isMP3FrameHeader(skipID3v2(data)):
+		return FormatMP3
+	case len(data) >= 12 && string(data[4:8]) == "ftyp" && mp4Brands[string(data[8:12])]:
+		return FormatMP4
+	}
+	return ""
+}
+
+// isBMP 检查 BITMAPFILEHEADER 的保留字段和 DIB 头的长度, 避免把 "BM" 开头的文本当作 BMP.
+func isBMP(data []byte) bool {
+	if len(data) < 18 || !bytes.HasPrefix(data, []byte("BM")) {
+		return false
+	}
+	if data[6] != 0 || data[7] != 0 || data[8] != 0 || data[9] != 0 {
+		return false
+	}
+	switch binary.LittleEndian.Uint32(data[14:18]) {
+	case 12, 40, 52, 56, 64, 108, 124: // BITMAPCOREHEADER ... BITMAPV5HEADER
+		return true
+	}
+	return false
+}
+
+// isMP3FrameHeader 判断 data 是否以 MPEG Layer III 的帧头开始, AAC(ADTS) 的帧头 layer 为 0, 不是 MP3.
+func isMP3FrameHeader(data []byte) bool {
+	if len(data) < 4 || data[0] != 0xFF || data[1]&0xE0 != 0xE0 {
+		return false
+	}
+	version := (data[1] >> 3) & 0x03
+	layer := (data[1] >> 1) & 0x03
+	bitRateIndex := data[2] >> 4
+	sampleRateIndex := (data[2] >> 2) & 0x03
+	return version != 1 && layer == 1 && bitRateIndex != 0x0F && sampleRateIndex != 3
+}
+
+// skipID3v2 跳过 ID3v2 标签, 没有标签返回 data, 标签不完整返回 nil.
+func skipID3v2(data []byte) []byte {
+	if !bytes.HasPrefix(data, []byte("ID3")) {
+		return data
+	}
+	if len(data) < 10 {
+		return nil
+	}
+	size := int(data[6]&0x7F)<<21 | int(data[7]&0x7F)<<14 | int(data[8]&0x7F)<<7 | int(data[9]&0x7F)
+	size += 10
+	if data[5]&0x10 != 0 {
+		size += 10 // footer
+	}
+	if size > len(data) {
+		return nil
+	}
+	return data[size:]
+}
+
+// mp4Brands 是微信支持的 MP4 视频的 ftyp major brand, 不包括 M4A(音频), qt(MOV), 3gp, heic 等.
+var mp4Brands = map[string]bool{
+	"isom": true,
+	"iso2": true,
+	"iso4": true,
+	"iso5": true,
+	"iso6": true,
+	"mp41": true,
+	"mp42": true,
+	"avc1": true,
+	"dash": true,
+	"M4V ": true,
+	"M4VH": true,
+	"M4VP": true,
+	"MSNV": true,
+}
+
+// formatExt 返回格式对应的文件扩展名.
+func formatExt(format string) string {
+	if format == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + format
+}
+
+// fixExt 把 filename 的扩展名修改为 format 对应的扩展名, 微信服务器根据扩展名判断文件类型.
+func fixExt(filename, format string) string {
+	ext := filepath.Ext(filename)
+	switch strings.ToLower(ext) {
+	case formatExt(format), "." + format:
+		return filename
+	}
+	return strings.TrimSuffix(filename, ext) + formatExt(format)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// amrDuration 返回 AMR(AMR-NB 或 AMR-WB) 文件的播放长度, 每帧 20ms.
+func amrDuration(data []byte) time.Duration {
+	var frameSizes []int
+	switch {
+	case bytes.HasPrefix(data, []byte("#!AMR\n")):
+		data = data[len("#!AMR\n"):]
+		frameSizes = []int{12, 13, 15, 17, 19, 20, 26, 31, 5, 0, 0, 0, 0, 0, 0, 0}
+	case bytes.HasPrefix(data, []byte("#!AMR-WB\n")):
+		data = data[len("#!AMR-WB\n"):]
+		frameSizes = []int{17, 23, 32, 36, 40, 46, 50, 58, 60, 5, 0, 0, 0, 0, 0, 0}
+	default:
+		return 0
+	}
+	frames := 0
+	for len(data) > 0 {
+		size := 1 + frameSizes[(data[0]>>3)&0x0F]
+		if size > len(data) {
+			break
+		}
+		data = data[size:]
+		frames++
+	}
+	return time.Duration(frames) * 20 * time.Millisecond
+}
+
+var (
+	mp3BitRatesV1   = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mp3BitRatesV2   = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+	mp3SampleRates1 = [4]int{44100, 48000, 32000, 0}
+)
+
+// mp3Duration 遍历 MP3(MPEG Layer III) 文件的全部帧计算播放长度, 支持 VBR.
+func mp3Duration(data []byte) time.Duration {
+	data = skipID3v2(data)
+
+	var seconds float64
+	for i := 0; i+4 <= len(data); {
+		if !isMP3FrameHeader(data[i:]) {
+			i++ // 重新同步
+			continue
+		}
+		version := (data[i+1] >> 3) & 0x03 // 0: MPEG 2.5, 2: MPEG 2, 3: MPEG 1
+		bitRateIndex := data[i+2] >> 4
+		sampleRateIndex := (data[i+2] >> 2) & 0x03
+		padding := int((data[i+2] >> 1) & 0x01)
+
+		sampleRate := mp3SampleRates1[sampleRateIndex]
+		var bitRate, samples, frameSize int
+		if version == 3 {
+			bitRate = mp3BitRatesV1[bitRateIndex] * 1000
+			samples = 1152
+		} else {
+			bitRate = mp3BitRatesV2[bitRateIndex] * 1000
+			samples = 576
+			sampleRate /= 2
+			if version == 0 {
+				sampleRate /= 2
+			}
+		}
+		if bitRate == 0 {
+			i++
+			continue
+		}
+		frameSize = samples/8*bitRate/sampleRate + padding
+		seconds += float64(samples) / float64(sampleRate)
+		i += frameSize
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,126 @@
+package media
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		data   string
+		format string
+	}{
+		{"\xFF\xD8\xFF\xE0\x00\x10JFIF", FormatJPEG},
+		{"\x89PNG\r\n\x1A\n\x00\x00", FormatPNG},
+		{"GIF89a\x01\x00", FormatGIF},
+		{"BM\x46\x00\x00\x00\x00\x00\x00\x00\x36\x00\x00\x00\x28\x00\x00\x00", FormatBMP},
+		{"BMW is a car brand", ""},
+		{"#!AMR\n", FormatAMR},
+		{string(mp3Data(1)), FormatMP3},
+		{"ID3\x04\x00", ""},
+		{"\xFF\xFB\x90\x00", FormatMP3},
+		{"\xFF\xF1\x50\x80", ""}, // AAC(ADTS)
+		{"\x00\x00\x00\x18ftypmp42", FormatMP4},
+		{"\x00\x00\x00\x20ftypisom", FormatMP4},
+		{"\x00\x00\x00\x20ftypM4A ", ""},
+		{"\x00\x00\x00\x14ftypqt  ", ""},
+		{"\x00\x00\x00\x18ftyp3gp4", ""},
+		{"\x00\x00\x00\x18ftypheic", ""},
+		{"hello world", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat([]byte(tt.data)); got != tt.format {
+			t.Errorf("DetectFormat(%q) = %q, want %q", tt.data, got, tt.format)
+		}
+	}
+}
+
+func amrData(frames int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("#!AMR\n")
+	frame := make([]byte, 32)
+	frame[0] = 7 << 3 // MR122, 31 bytes
+	for i := 0; i < frames; i++ {
+		buf.Write(frame)
+	}
+	return buf.Bytes()
+}
+
+func mp3Data(frames int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("ID3\x04\x00\x00\x00\x00\x00\x02\x00\x00") // 2 bytes tag
+	frame := make([]byte, 417)                                 // MPEG 1 Layer III, 128kbps, 44100Hz
+	copy(frame, "\xFF\xFB\x90\x00")
+	for i := 0; i < frames; i++ {
+		buf.Write(frame)
+	}
+	return buf.Bytes()
+}
+
+func TestCheck(t *testing.T) {
+	jpeg := append([]byte("\xFF\xD8\xFF\xE0"), make([]byte, 100)...)
+	if err := Check(MediaTypeThumb, jpeg); err != nil {
+		t.Errorf("Check thumb: %v", err)
+	}
+
+	big := append([]byte("\xFF\xD8\xFF\xE0"), make([]byte, MaxThumbSize)...)
+	err := Check(MediaTypeThumb, big)
+	if e, ok := err.(*SizeError); !ok || e.Size != int64(len(big)) || e.MaxSize != MaxThumbSize {
+		t.Errorf("Check big thumb: %#v", err)
+	}
+
+	err = Check(MediaTypeThumb, []byte("\x89PNG\r\n\x1A\n\x00\x00"))
+	if e, ok := err.(*FormatError); !ok || e.Format != FormatPNG {
+		t.Errorf("Check png thumb: %#v", err)
+	}
+	if err := Check(MediaTypeImage, []byte("\x89PNG\r\n\x1A\n\x00\x00")); err != nil {
+		t.Errorf("Check png image: %v", err)
+	}
+	if err = Check(MediaTypeVideo, []byte("hello")); err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Errorf("Check unknown video: %v", err)
+	}
+
+	if err := Check(MediaTypeVoice, amrData(3000)); err != nil { // 60s
+		t.Errorf("Check amr: %v", err)
+	}
+	err = Check(MediaTypeVoice, amrData(3001))
+	if e, ok := err.(*DurationError); !ok || e.Duration != 60020*time.Millisecond {
+		t.Errorf("Check long amr: %#v", err)
+	}
+
+	if d := mp3Duration(mp3Data(100)); d < 2612*time.Millisecond || d > 2613*time.Millisecond {
+		t.Errorf("mp3Duration = %s", d)
+	}
+	if err, ok := Check(MediaTypeVoice, mp3Data(2400)).(*DurationError); !ok {
+		t.Errorf("Check long mp3: %v", err)
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1A\n\x00\x00")
+	if _, _, err := Prepare(MediaTypeThumb, "a.png", bytes.NewReader(png), nil); err == nil {
+		t.Fatal("want error")
+	}
+
+	var reason error
+	transcoder := TranscoderFunc(func(mediaType string, data []byte, err error) ([]byte, error) {
+		reason = err
+		return []byte("\xFF\xD8\xFF\xE0\x00\x00"), nil
+	})
+	name, data, err := Prepare(MediaTypeThumb, "a.png", bytes.NewReader(png), transcoder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reason.(*FormatError); !ok {
+		t.Errorf("reason = %#v", reason)
+	}
+	if name != "a.jpg" || DetectFormat(data) != FormatJPEG {
+		t.Errorf("Prepare = %q, %q", name, data)
+	}
+
+	if name, _, _ = Prepare(MediaTypeImage, "b.JPEG", bytes.NewReader(data), nil); name != "b.JPEG" {
+		t.Errorf("name = %q", name)
+	}
+}
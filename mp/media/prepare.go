@@ -0,0 +1,100 @@
+package media
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+// Transcoder 把不满足限制的文件转换为满足限制的文件, 比如缩放, 重新压缩图片以满足缩略图 64KB 的限制.
+type Transcoder interface {
+	// Transcode 转换 data, reason 是 Check 返回的错误(*SizeError, *FormatError 或 *DurationError).
+	// 不能转换的返回 reason 或者其他的错误.
+	Transcode(mediaType string, data []byte, reason error) ([]byte, error)
+}
+
+var _ Transcoder = TranscoderFunc(nil)
+
+type TranscoderFunc func(mediaType string, data []byte, reason error) ([]byte, error)
+
+func (fn TranscoderFunc) Transcode(mediaType string, data []byte, reason error) ([]byte, error) {
+	return fn(mediaType, data, reason)
+}
+
+// Prepare 读取 reader 的全部内容并调用 Check 检查, 不满足限制并且 transcoder != nil 时调用 transcoder 转换后再次检查.
+// 返回的 name 是根据文件内容修正了扩展名的 filename, 和 data 一起用于上传临时素材或者永久素材, 比如:
+//
+//	name, data, err := media.Prepare(media.MediaTypeThumb, filename, reader, transcoder)
+//	if err != nil {
+//		return err
+//	}
+//	mediaId, url, err := material.UploadThumbFromReader(clt, name, bytes.NewReader(data))
+func Prepare(mediaType, filename string, reader io.Reader, transcoder Transcoder) (name string, data []byte, err error) {
+	data, err = ioutil.ReadAll(reader)
+	if err != nil {
+		return
+	}
+	if err = Check(mediaType, data); err != nil {
+		if transcoder == nil {
+			return
+		}
+		if data, err = transcoder.Transcode(mediaType, data, err); err != nil {
+			return
+		}
+		if err = Check(mediaType, data); err != nil {
+			return
+		}
+	}
+	name = fixExt(filename, DetectFormat(data))
+	return
+}
+
+// PrepareUpload 调用 Prepare 检查(转换)文件, 通过之后调用 upload 上传修正了扩展名的文件,
+// 不满足限制的文件不会调用 upload. 临时素材的 Uploader 和永久素材的 material.Uploader 都使用这个函数.
+func PrepareUpload(mediaType, filename string, reader io.Reader, transcoder Transcoder, upload func(filename string, reader io.Reader) error) error {
+	name, data, err := Prepare(mediaType, filename, reader, transcoder)
+	if err != nil {
+		return err
+	}
+	return upload(name, bytes.NewReader(data))
+}
+
+// Uploader 在上传临时素材之前调用 Prepare 检查(转换)文件, 不满足限制的文件不会上传.
+type Uploader struct {
+	Client     *core.Client
+	Transcoder Transcoder // 可选
+}
+
+// UploadImageFromReader 检查并上传多媒体图片
+func (u *Uploader) UploadImageFromReader(filename string, reader io.Reader) (info *MediaInfo, err error) {
+	return u.upload(MediaTypeImage, filename, reader)
+}
+
+// UploadVoiceFromReader 检查并上传多媒体语音
+func (u *Uploader) UploadVoiceFromReader(filename string, reader io.Reader) (info *MediaInfo, err error) {
+	return u.upload(MediaTypeVoice, filename, reader)
+}
+
+// UploadVideoFromReader 检查并上传多媒体视频
+func (u *Uploader) UploadVideoFromReader(filename string, reader io.Reader) (info *MediaInfo, err error) {
+	return u.upload(MediaTypeVideo, filename, reader)
+}
+
+// UploadThumbFromReader 检查并上传多媒体缩略图
+func (u *Uploader) UploadThumbFromReader(filename string, reader io.Reader) (info *MediaInfo, err error) {
+	return u.upload(MediaTypeThumb, filename, reader)
+}
+
+func (u *Uploader) upload(mediaType, filename string, reader io.Reader) (info *MediaInfo, err error) {
+	err = PrepareUpload(mediaType, filename, reader, u.Transcoder, func(name string, r io.Reader) (err error) {
+		if mediaType == MediaTypeThumb {
+			info, err = UploadThumbFromReader(u.Client, name, r)
+		} else {
+			info, err = uploadFromReader(u.Client, mediaType, name, r)
+		}
+		return
+	})
+	return
+}
@@ -0,0 +1,213 @@
+// 用文件声明式的管理自定义菜单(默认菜单和个性化菜单), 对比线上的菜单, 只应用需要的变更.
+//
+//	conf, err := deploy.Load("menu.json")
+//	if err != nil {
+//	    // TODO: 增加你的代码
+//	}
+//	plan, err := deploy.NewPlan(clt, conf)
+//	if err != nil {
+//	    // TODO: 增加你的代码
+//	}
+//	fmt.Print(plan) // 打印变更
+//	if err = plan.Apply(clt); err != nil {
+//	    // TODO: 增加你的代码
+//	}
+//
+//	配置文件为 JSON 格式, 菜单的格式和 menu.Create, menu.AddConditionalMenu 接口的参数一致:
+//	{
+//	    "menu": {"button": [...]},
+//	    "conditionalmenu": [
+//	        {"button": [...], "matchrule": {"tag_id": "2"}}
+//	    ]
+//	}
+//
+//	其他格式(比如 YAML)的配置文件可以用 LoadWith 或者 ParseWith 指定解析函数, 字段和 JSON 格式一致:
+//	conf, err := deploy.LoadWith("menu.yaml", yaml.Unmarshal) // gopkg.in/yaml.v3
+//
+//	menu:
+//	  button:
+//	    - type: click
+//	      name: 今日歌曲
+//	      key: V1001_TODAY_MUSIC
+//	conditionalmenu:
+//	  - button: [...]
+//	    matchrule:
+//	      tag_id: "2"
+package deploy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/chanxuehong/wechat/mp/core"
+	"github.com/chanxuehong/wechat/mp/menu"
+)
+
+// Config 是声明的全部菜单.
+type Config struct {
+	Menu             menu.Menu   `json:"menu"`                      // 默认菜单, 没有按钮表示删除全部菜单
+	ConditionalMenus []menu.Menu `json:"conditionalmenu,omitempty"` // 个性化菜单, 按照 MatchRule 区分
+
+	// 是否为第三方平台旗下未微信认证的订阅号, 只有这类公众号可以使用 media_id 和 view_limited 类型的按钮
+	ThirdParty bool `json:"third_party,omitempty"`
+}
+
+// Load 读取并解析 JSON 格式的 filename 文件, 并且校验菜单是否合法.
+//
+//	扩展名为 .yaml 或者 .yml 的文件返回错误, 请使用 LoadWith 并指定 YAML 的解析函数.
+func Load(filename string) (conf *Config, err error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		err = fmt.Errorf("%s: yaml config requires LoadWith with a yaml unmarshal function", filename)
+		return
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	return Parse(data)
+}
+
+// LoadWith 读取 filename 文件, 用 unmarshal 解析(参考 ParseWith), 并且校验菜单是否合法.
+func LoadWith(filename string, unmarshal func(data []byte, v interface{}) error) (conf *Config, err error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	return ParseWith(data, unmarshal)
+}
+
+// ParseWith 用 unmarshal 解析其他格式的菜单配置, 字段和 JSON 格式一致, 并且校验菜单是否合法.
+//
+//	unmarshal 把 data 解析到 *interface{}, 比如 gopkg.in/yaml.v2 或者 gopkg.in/yaml.v3 的 yaml.Unmarshal;
+//	解析的结果转换为 JSON 之后再调用 Parse, 所以和 JSON 格式一样不允许未知的字段.
+func ParseWith(data []byte, unmarshal func(data []byte, v interface{}) error) (conf *Config, err error) {
+	var value interface{}
+	if err = unmarshal(data, &value); err != nil {
+		return
+	}
+	if value, err = jsonValue(value); err != nil {
+		return
+	}
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	return Parse(jsonData)
+}
+
+// jsonValue 把 map[interface{}]interface{}(比如 yaml.v2 的解析结果)转换为 json.Marshal 支持的 map[string]interface{}.
+func jsonValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			s, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("unsupported key %v of type %T", key, key)
+			}
+			elem, err := jsonValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			m[s] = elem
+		}
+		return m, nil
+	case map[string]interface{}:
+		for key, elem := range v {
+			elem, err := jsonValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = elem
+		}
+		return v, nil
+	case []interface{}:
+		for i, elem := range v {
+			elem, err := jsonValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = elem
+		}
+		return v, nil
+	default:
+		return value, nil
+	}
+}
+
+// Parse 解析 JSON 格式的菜单配置, 并且校验菜单是否合法.
+func Parse(data []byte) (conf *Config, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var c Config
+	if err = decoder.Decode(&c); err != nil {
+		return
+	}
+	if err = c.Validate(); err != nil {
+		return
+	}
+	conf = &c
+	return
+}
+
+// Validate 校验菜单配置, 如果不合法返回 *core.ValidationError.
+//
+//	每个菜单的规则参考 menu.Menu.Validate, 另外还检查:
+//	默认菜单不能有 matchrule; 有个性化菜单的时候必须有默认菜单; 个性化菜单必须有 matchrule 并且不能重复.
+func (conf *Config) Validate() error {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	checkMenu := func(path string, m *menu.Menu) {
+		var err error
+		if conf.ThirdParty {
+			err = m.ValidateThirdParty()
+		} else {
+			err = m.Validate()
+		}
+		if err == nil {
+			return
+		}
+		for _, problem := range err.(*core.ValidationError).Problems {
+			problems = append(problems, path+"."+problem)
+		}
+	}
+
+	if len(conf.Menu.Buttons) > 0 {
+		if conf.Menu.MatchRule != nil {
+			addf("menu.matchrule: default menu can not have matchrule")
+		}
+		checkMenu("menu", &conf.Menu)
+	} else if len(conf.ConditionalMenus) > 0 {
+		addf("menu.button: conditional menus require a default menu")
+	}
+
+	rules := make(map[string]int, len(conf.ConditionalMenus))
+	for i := range conf.ConditionalMenus {
+		m := &conf.ConditionalMenus[i]
+		path := fmt.Sprintf("conditionalmenu[%d]", i)
+		if m.MatchRule == nil {
+			addf("%s.matchrule: required", path)
+		} else {
+			rule := matchRuleString(m.MatchRule)
+			if j, ok := rules[rule]; ok {
+				addf("%s.matchrule: duplicate with conditionalmenu[%d]", path, j)
+			} else {
+				rules[rule] = i
+			}
+		}
+		checkMenu(path, m)
+	}
+
+	if len(problems) > 0 {
+		return &core.ValidationError{Subject: "menu config", Problems: problems}
+	}
+	return nil
+}
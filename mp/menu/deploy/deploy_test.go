@@ -0,0 +1,217 @@
+package deploy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+	"github.com/chanxuehong/wechat/mp/core"
+	"github.com/chanxuehong/wechat/mp/menu"
+)
+
+const testConfig = `{
+	"menu": {
+		"button": [
+			{"type": "click", "name": "今日歌曲", "key": "V1001_TODAY_MUSIC"},
+			{"name": "菜单", "sub_button": [
+				{"type": "view", "name": "搜索", "url": "http://www.soso.com/"},
+				{"type": "click", "name": "赞一下我们", "key": "V1001_GOOD"}
+			]}
+		]
+	},
+	"conditionalmenu": [
+		{"button": [{"type": "click", "name": "男士专区", "key": "MALE"}], "matchrule": {"sex": "1"}},
+		{"button": [{"type": "click", "name": "女士专区", "key": "FEMALE"}], "matchrule": {"sex": "2"}}
+	]
+}`
+
+func TestDiff(t *testing.T) {
+	conf, err := Parse([]byte(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 线上菜单和配置一致
+	current := conf.Menu
+	currentConditionalMenus := []menu.Menu{conf.ConditionalMenus[0], conf.ConditionalMenus[1]}
+	currentConditionalMenus[0].MenuId = 1
+	currentConditionalMenus[1].MenuId = 2
+	if plan := Diff(&current, currentConditionalMenus, conf); !plan.Empty() {
+		t.Fatalf("want empty plan, have:\n%s", plan)
+	}
+
+	// 修改默认菜单的一个按钮, 修改一个个性化菜单, 删除一个个性化菜单
+	current.Buttons = append([]menu.Button(nil), conf.Menu.Buttons...)
+	current.Buttons[0].Key = "V1001_OLD"
+	currentConditionalMenus = []menu.Menu{
+		{MenuId: 1, MatchRule: &menu.MatchRule{Sex: "1"}, Buttons: []menu.Button{{Type: "click", Name: "男士", Key: "MALE"}}},
+		{MenuId: 3, MatchRule: &menu.MatchRule{TagId: "100"}, Buttons: []menu.Button{{Type: "click", Name: "VIP", Key: "VIP"}}},
+	}
+	plan := Diff(&current, currentConditionalMenus, conf)
+	if plan.CreateMenu == nil {
+		t.Error("want CreateMenu")
+	}
+	if len(plan.DeleteConditionalMenus) != 2 || plan.DeleteConditionalMenus[0].MenuId != 1 || plan.DeleteConditionalMenus[1].MenuId != 3 {
+		t.Errorf("unexpected DeleteConditionalMenus: %+v", plan.DeleteConditionalMenus)
+	}
+	if len(plan.AddConditionalMenus) != 2 {
+		t.Errorf("unexpected AddConditionalMenus: %+v", plan.AddConditionalMenus)
+	}
+	want := `默认菜单: 修改
+  - 今日歌曲 (click key=V1001_OLD)
+  + 今日歌曲 (click key=V1001_TODAY_MUSIC)
+    菜单
+        搜索 (view url=http://www.soso.com/)
+        赞一下我们 (click key=V1001_GOOD)
+个性化菜单 matchrule{sex=1} (menuid=1): 修改
+  - 男士 (click key=MALE)
+  + 男士专区 (click key=MALE)
+个性化菜单 matchrule{tag_id=100} (menuid=3): 删除
+  - VIP (click key=VIP)
+个性化菜单 matchrule{sex=2}: 新增
+  + 女士专区 (click key=FEMALE)
+`
+	if have := plan.String(); have != want {
+		t.Errorf("have:\n%s\nwant:\n%s", have, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	_, err := Parse([]byte(`{
+		"menu": {"button": [
+			{"type": "click", "name": "一二三四五六七八九"},
+			{"type": "view", "name": "b"},
+			{"name": "c", "sub_button": [{"type": "click", "name": "d", "key": "D"}]},
+			{"type": "click", "name": "e", "key": "E"}
+		]},
+		"conditionalmenu": [{"button": [{"type": "click", "name": "f", "key": "F"}]}]
+	}`))
+	verr, ok := err.(*core.ValidationError)
+	if !ok {
+		t.Fatalf("want *core.ValidationError, have %v", err)
+	}
+	for _, want := range []string{
+		"menu.button: too many buttons",
+		"menu.button[0].name: too long",
+		"menu.button[0].key: required",
+		"menu.button[1].url: required",
+		"conditionalmenu[0].matchrule: required",
+	} {
+		if !strings.Contains(verr.Error(), want) {
+			t.Errorf("error %q does not contain %q", verr.Error(), want)
+		}
+	}
+}
+
+func TestApplyOrder(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, strings.TrimPrefix(r.URL.Path, "/cgi-bin/menu/"))
+		io.WriteString(w, `{"errcode":0,"errmsg":"ok","menuid":100}`)
+	}))
+	defer srv.Close()
+
+	plan := &Plan{
+		CreateMenu:             &menu.Menu{Buttons: []menu.Button{{Type: "click", Name: "a", Key: "A"}}},
+		DeleteConditionalMenus: []menu.Menu{{MenuId: 1, MatchRule: &menu.MatchRule{Sex: "1"}}},
+		AddConditionalMenus:    []menu.Menu{{MatchRule: &menu.MatchRule{Sex: "1"}, Buttons: []menu.Button{{Type: "click", Name: "b", Key: "B"}}}},
+	}
+	if err := plan.Apply(testutil.NewClient(srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+	// 先新增个性化菜单再删除旧的
+	if want := []string{"create", "addconditional", "delconditional"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("have %v, want %v", paths, want)
+	}
+}
+
+func TestApplyConditionalMenuLimit(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/cgi-bin/menu/")
+		paths = append(paths, path)
+		// 删除旧的菜单之前数量达到上限
+		if path == "addconditional" && len(paths) == 1 {
+			io.WriteString(w, `{"errcode":65305,"errmsg":"too many conditional menus"}`)
+			return
+		}
+		io.WriteString(w, `{"errcode":0,"errmsg":"ok","menuid":100}`)
+	}))
+	defer srv.Close()
+
+	plan := &Plan{
+		DeleteConditionalMenus: []menu.Menu{{MenuId: 1, MatchRule: &menu.MatchRule{Sex: "1"}}},
+		AddConditionalMenus: []menu.Menu{
+			{MatchRule: &menu.MatchRule{Sex: "1"}, Buttons: []menu.Button{{Type: "click", Name: "b", Key: "B"}}},
+			{MatchRule: &menu.MatchRule{Sex: "2"}, Buttons: []menu.Button{{Type: "click", Name: "c", Key: "C"}}},
+		},
+	}
+	if err := plan.Apply(testutil.NewClient(srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"addconditional", "delconditional", "addconditional", "addconditional"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("have %v, want %v", paths, want)
+	}
+
+	// 没有可以删除的菜单, 直接返回错误
+	paths = nil
+	plan.DeleteConditionalMenus = nil
+	if err := plan.Apply(testutil.NewClient(srv.URL)); err == nil || !strings.Contains(err.Error(), "65305") {
+		t.Errorf("want limit error, have %v", err)
+	}
+}
+
+func TestParseWith(t *testing.T) {
+	want, err := Parse([]byte(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 和 yaml.v2 一样解析为 map[interface{}]interface{}
+	unmarshal := func(data []byte, v interface{}) error {
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*(v.(*interface{})) = toInterfaceKeys(value)
+		return nil
+	}
+	have, err := ParseWith([]byte(testConfig), unmarshal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("have %+v, want %+v", have, want)
+	}
+
+	// 未知的字段和不合法的菜单
+	for _, src := range []string{`{"menu": {"button": []}, "unknown": 1}`, `{"menu": {"button": [{"type": "click"}]}}`} {
+		if _, err = ParseWith([]byte(src), json.Unmarshal); err == nil {
+			t.Errorf("want error for %s", src)
+		}
+	}
+	if _, err = Load("menu.yaml"); err == nil || !strings.Contains(err.Error(), "LoadWith") {
+		t.Errorf("Load yaml: have %v", err)
+	}
+}
+
+func toInterfaceKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[interface{}]interface{}, len(v))
+		for key, elem := range v {
+			m[key] = toInterfaceKeys(elem)
+		}
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = toInterfaceKeys(v[i])
+		}
+	}
+	return value
+}
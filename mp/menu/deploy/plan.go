@@ -0,0 +1,301 @@
+package deploy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chanxuehong/wechat/mp/core"
+	"github.com/chanxuehong/wechat/mp/menu"
+)
+
+// Plan 是把线上的菜单变更为 Config 声明的菜单需要的操作.
+//
+//	NOTE: 个性化菜单不能修改, 只能删除后重新创建; 删除默认菜单会同时删除全部的个性化菜单.
+type Plan struct {
+	CreateMenu *menu.Menu // 需要调用 menu.Create 创建(覆盖)默认菜单, nil 表示默认菜单不需要变更
+	DeleteMenu bool       // 需要调用 menu.Delete 删除全部菜单
+
+	DeleteConditionalMenus []menu.Menu // 需要删除的个性化菜单, MenuId 有效
+	AddConditionalMenus    []menu.Menu // 需要新增的个性化菜单
+
+	diff []string // 人类可读的变更
+}
+
+// NewPlan 查询线上的菜单, 然后和 conf 对比生成 Plan.
+func NewPlan(clt *core.Client, conf *Config) (plan *Plan, err error) {
+	current, currentConditionalMenus, err := menu.Get(clt)
+	if err != nil {
+		if e, ok := err.(*core.Error); !ok || e.ErrCode != errCodeMenuNotExist {
+			return
+		}
+		// 没有菜单
+		current, currentConditionalMenus, err = &menu.Menu{}, nil, nil
+	}
+	return Diff(current, currentConditionalMenus, conf), nil
+}
+
+// 菜单不存在的错误码, menu.Get 在没有创建过菜单的时候返回
+const errCodeMenuNotExist = 46003
+
+// Diff 对比线上的菜单(menu.Get 的返回值)和 conf, 生成 Plan.
+func Diff(current *menu.Menu, currentConditionalMenus []menu.Menu, conf *Config) *Plan {
+	plan := &Plan{}
+
+	if len(conf.Menu.Buttons) == 0 {
+		if len(current.Buttons) > 0 || len(currentConditionalMenus) > 0 {
+			plan.DeleteMenu = true
+			plan.addDiff("默认菜单: 删除(同时删除全部个性化菜单)", buttonLines(current.Buttons), nil)
+			for i := range currentConditionalMenus {
+				m := &currentConditionalMenus[i]
+				plan.addDiff(conditionalMenuTitle(m)+": 删除", buttonLines(m.Buttons), nil)
+			}
+		}
+		return plan
+	}
+
+	if !buttonsEqual(current.Buttons, conf.Menu.Buttons) {
+		m := menu.Menu{Buttons: conf.Menu.Buttons}
+		plan.CreateMenu = &m
+		title := "默认菜单: 修改"
+		if len(current.Buttons) == 0 {
+			title = "默认菜单: 新增"
+		}
+		plan.addDiff(title, buttonLines(current.Buttons), buttonLines(conf.Menu.Buttons))
+	}
+
+	// 按照 MatchRule 匹配个性化菜单
+	matched := make([]bool, len(conf.ConditionalMenus))
+	for i := range currentConditionalMenus {
+		m := &currentConditionalMenus[i]
+		j := indexOfMatchRule(conf.ConditionalMenus, m.MatchRule)
+		if j >= 0 && !matched[j] && buttonsEqual(m.Buttons, conf.ConditionalMenus[j].Buttons) {
+			matched[j] = true
+			continue
+		}
+		plan.DeleteConditionalMenus = append(plan.DeleteConditionalMenus, *m)
+		if j >= 0 && !matched[j] {
+			// MatchRule 相同但是按钮不同, 删除后重新创建
+			matched[j] = true
+			plan.AddConditionalMenus = append(plan.AddConditionalMenus, conf.ConditionalMenus[j])
+			plan.addDiff(conditionalMenuTitle(m)+": 修改", buttonLines(m.Buttons), buttonLines(conf.ConditionalMenus[j].Buttons))
+			continue
+		}
+		plan.addDiff(conditionalMenuTitle(m)+": 删除", buttonLines(m.Buttons), nil)
+	}
+	for j := range conf.ConditionalMenus {
+		if matched[j] {
+			continue
+		}
+		m := &conf.ConditionalMenus[j]
+		plan.AddConditionalMenus = append(plan.AddConditionalMenus, *m)
+		plan.addDiff(conditionalMenuTitle(m)+": 新增", nil, buttonLines(m.Buttons))
+	}
+	return plan
+}
+
+// Empty 返回 true 如果没有需要变更的菜单.
+func (plan *Plan) Empty() bool {
+	return plan.CreateMenu == nil && !plan.DeleteMenu &&
+		len(plan.DeleteConditionalMenus) == 0 && len(plan.AddConditionalMenus) == 0
+}
+
+// String 返回人类可读的变更, "-" 开头的行表示删除, "+" 开头的行表示新增.
+func (plan *Plan) String() string {
+	if plan.Empty() {
+		return "菜单没有变更\n"
+	}
+	return strings.Join(plan.diff, "\n") + "\n"
+}
+
+// ErrCodeConditionalMenuLimit 是个性化菜单数量达到上限的错误码.
+const ErrCodeConditionalMenuLimit = 65305
+
+// Apply 执行变更, 遇到错误立即返回, 已经执行的变更不会回滚, 修正问题之后再次执行 NewPlan 和 Apply 即可.
+//
+//	执行顺序: 删除全部菜单 -> 创建默认菜单 -> 新增个性化菜单 -> 删除个性化菜单.
+//	微信按照创建时间从新到旧匹配个性化菜单, 先新增再删除, 修改个性化菜单的时候新菜单立即生效,
+//	不会出现某些用户没有个性化菜单的情况;
+//	如果新增的时候个性化菜单的数量达到上限(ErrCodeConditionalMenuLimit), 则先删除需要删除的个性化菜单再重试.
+func (plan *Plan) Apply(clt *core.Client) (err error) {
+	if plan.DeleteMenu {
+		if err = menu.Delete(clt); err != nil {
+			return fmt.Errorf("delete menu failed: %s", err.Error())
+		}
+	}
+	if plan.CreateMenu != nil {
+		if err = menu.Create(clt, plan.CreateMenu); err != nil {
+			return fmt.Errorf("create menu failed: %s", err.Error())
+		}
+	}
+
+	deleted := false
+	deleteConditionalMenus := func() error {
+		deleted = true
+		for i := range plan.DeleteConditionalMenus {
+			m := &plan.DeleteConditionalMenus[i]
+			if err := menu.DeleteConditionalMenu(clt, m.MenuId); err != nil {
+				return fmt.Errorf("delete conditional menu %d failed: %s", m.MenuId, err.Error())
+			}
+		}
+		return nil
+	}
+	for i := range plan.AddConditionalMenus {
+		m := menu.Menu{
+			Buttons:   plan.AddConditionalMenus[i].Buttons,
+			MatchRule: plan.AddConditionalMenus[i].MatchRule,
+		}
+		_, err = menu.AddConditionalMenu(clt, &m)
+		if isConditionalMenuLimit(err) && !deleted && len(plan.DeleteConditionalMenus) > 0 {
+			if err = deleteConditionalMenus(); err != nil {
+				return
+			}
+			_, err = menu.AddConditionalMenu(clt, &m)
+		}
+		if err != nil {
+			return fmt.Errorf("add conditional menu %s failed: %s", matchRuleString(m.MatchRule), err.Error())
+		}
+	}
+	if !deleted {
+		err = deleteConditionalMenus()
+	}
+	return
+}
+
+func isConditionalMenuLimit(err error) bool {
+	e, ok := err.(*core.Error)
+	return ok && e.ErrCode == ErrCodeConditionalMenuLimit
+}
+
+func (plan *Plan) addDiff(title string, oldLines, newLines []string) {
+	plan.diff = append(plan.diff, title)
+	plan.diff = append(plan.diff, diffLines(oldLines, newLines)...)
+}
+
+func indexOfMatchRule(menus []menu.Menu, rule *menu.MatchRule) int {
+	s := matchRuleString(rule)
+	for i := range menus {
+		if matchRuleString(menus[i].MatchRule) == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func conditionalMenuTitle(m *menu.Menu) string {
+	if m.MenuId != 0 {
+		return fmt.Sprintf("个性化菜单 %s (menuid=%d)", matchRuleString(m.MatchRule), m.MenuId)
+	}
+	return "个性化菜单 " + matchRuleString(m.MatchRule)
+}
+
+func matchRuleString(rule *menu.MatchRule) string {
+	if rule == nil {
+		return "matchrule{}"
+	}
+	var fields []string
+	add := func(name, value string) {
+		if value != "" {
+			fields = append(fields, name+"="+value)
+		}
+	}
+	add("tag_id", rule.TagId)
+	add("group_id", rule.GroupId)
+	add("sex", rule.Sex)
+	add("country", rule.Country)
+	add("province", rule.Province)
+	add("city", rule.City)
+	add("client_platform_type", rule.ClientPlatformType)
+	add("language", rule.Language)
+	return "matchrule{" + strings.Join(fields, ",") + "}"
+}
+
+func buttonsEqual(a, b []menu.Button) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		x, y := &a[i], &b[i]
+		if x.Type != y.Type || x.Name != y.Name || x.Key != y.Key || x.URL != y.URL ||
+			x.MediaId != y.MediaId || x.AppId != y.AppId || x.PagePath != y.PagePath {
+			return false
+		}
+		if !buttonsEqual(x.SubButtons, y.SubButtons) {
+			return false
+		}
+	}
+	return true
+}
+
+// buttonLines 把菜单按钮转换为每个按钮一行的文本.
+func buttonLines(buttons []menu.Button) []string {
+	var lines []string
+	for i := range buttons {
+		lines = append(lines, buttonLine(&buttons[i], ""))
+		for j := range buttons[i].SubButtons {
+			lines = append(lines, buttonLine(&buttons[i].SubButtons[j], "    "))
+		}
+	}
+	return lines
+}
+
+func buttonLine(btn *menu.Button, indent string) string {
+	if len(btn.SubButtons) > 0 {
+		return indent + btn.Name
+	}
+	line := indent + btn.Name + " (" + btn.Type
+	add := func(name, value string) {
+		if value != "" {
+			line += " " + name + "=" + value
+		}
+	}
+	add("key", btn.Key)
+	add("url", btn.URL)
+	add("media_id", btn.MediaId)
+	add("appid", btn.AppId)
+	add("pagepath", btn.PagePath)
+	return line + ")"
+}
+
+// diffLines 基于最长公共子序列对比 oldLines 和 newLines.
+func diffLines(oldLines, newLines []string) []string {
+	m, n := len(oldLines), len(newLines)
+	lcs := make([][]int, m+1)
+	for i := range lcs {
+		lcs[i] = make([]int, n+1)
+	}
+	for i := m - 1; i >= 0; i-- {
+		for j := n - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < m && j < n {
+		switch {
+		case oldLines[i] == newLines[j]:
+			lines = append(lines, "    "+oldLines[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "  - "+oldLines[i])
+			i++
+		default:
+			lines = append(lines, "  + "+newLines[j])
+			j++
+		}
+	}
+	for ; i < m; i++ {
+		lines = append(lines, "  - "+oldLines[i])
+	}
+	for ; j < n; j++ {
+		lines = append(lines, "  + "+newLines[j])
+	}
+	return lines
+}
@@ -0,0 +1,232 @@
+package menu
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+var _ core.Handler = (*Router)(nil)
+
+// Router 在创建菜单按钮的同时注册按钮事件的 Handler, 保证菜单定义和事件路由一致.
+//
+//	router := menu.NewRouter()
+//	var m menu.Menu
+//	m.Buttons = []menu.Button{
+//	    router.ClickButtonFunc("今日歌曲", "V1001_TODAY_MUSIC", func(ctx *core.Context) {
+//	        // TODO: 事件处理逻辑
+//	    }),
+//	    router.ScanCodePushButtonFunc("扫一扫", "SCAN", func(ctx *core.Context) {
+//	        // TODO: 事件处理逻辑
+//	    }),
+//	}
+//	if err := router.Check(&m); err != nil { // 检查是否有没有 Handler 的按钮, 或者没有按钮的 Handler
+//	    // TODO: 增加你的代码
+//	}
+//	menu.Create(clt, &m)
+//
+//	mux := core.NewServeMux()
+//	router.Register(mux) // 注册 CLICK, scancode_push 事件的 Handler, 按照 EventKey 分发给上面的 Handler
+//
+//	NOTE: Router 非并发安全, 请在 Register 之前注册完全部的按钮.
+type Router struct {
+	routes map[core.EventType]map[string]core.Handler
+
+	// NotFound 处理没有注册 Handler 的 EventKey, 为 nil 时回复 "success"(core.Context.NoneResponse).
+	NotFound core.Handler
+}
+
+func NewRouter() *Router {
+	return &Router{
+		routes: make(map[core.EventType]map[string]core.Handler),
+	}
+}
+
+// 按钮类型对应的事件类型, 只有这些类型的按钮可以通过 Router 注册 Handler.
+var buttonEventTypes = map[string]core.EventType{
+	ButtonTypeClick:           EventTypeClick,
+	ButtonTypeScanCodePush:    EventTypeScanCodePush,
+	ButtonTypeScanCodeWaitMsg: EventTypeScanCodeWaitMsg,
+	ButtonTypePicSysPhoto:     EventTypePicSysPhoto,
+	ButtonTypePicPhotoOrAlbum: EventTypePicPhotoOrAlbum,
+	ButtonTypePicWeixin:       EventTypePicWeixin,
+	ButtonTypeLocationSelect:  EventTypeLocationSelect,
+}
+
+// Handle 注册 handler 处理事件类型为 eventType 并且 EventKey 为 key 的事件, 一般不需要直接调用, 使用 ClickButton 等方法.
+//
+//	同一个事件类型的 key 重复注册会 panic.
+func (r *Router) Handle(eventType core.EventType, key string, handler core.Handler) {
+	if key == "" {
+		panic("key can not be empty")
+	}
+	if handler == nil {
+		panic("handler can not be nil")
+	}
+	eventType = normalizeEventType(eventType)
+	m := r.routes[eventType]
+	if m == nil {
+		m = make(map[string]core.Handler)
+		r.routes[eventType] = m
+	}
+	if _, ok := m[key]; ok {
+		panic("duplicate key " + key + " for event " + string(eventType))
+	}
+	m[key] = handler
+}
+
+func (r *Router) button(buttonType, name, key string, handler core.Handler) (btn Button) {
+	r.Handle(buttonEventTypes[buttonType], key, handler)
+	btn.Type = buttonType
+	btn.Name = name
+	btn.Key = key
+	return
+}
+
+// ClickButton 返回 click 类型按钮, 同时注册 handler 处理该按钮的 CLICK 事件.
+func (r *Router) ClickButton(name, key string, handler core.Handler) Button {
+	return r.button(ButtonTypeClick, name, key, handler)
+}
+
+// ClickButtonFunc 同 ClickButton.
+func (r *Router) ClickButtonFunc(name, key string, handler func(*core.Context)) Button {
+	return r.button(ButtonTypeClick, name, key, core.HandlerFunc(handler))
+}
+
+// ScanCodePushButton 返回 扫码推事件 类型按钮, 同时注册 handler 处理该按钮的 scancode_push 事件.
+func (r *Router) ScanCodePushButton(name, key string, handler core.Handler) Button {
+	return r.button(ButtonTypeScanCodePush, name, key, handler)
+}
+
+// ScanCodePushButtonFunc 同 ScanCodePushButton.
+func (r *Router) ScanCodePushButtonFunc(name, key string, handler func(*core.Context)) Button {
+	return r.button(ButtonTypeScanCodePush, name, key, core.HandlerFunc(handler))
+}
+
+// ScanCodeWaitMsgButton 返回 扫码推事件且弹出"消息接收中"提示框 类型按钮, 同时注册 handler 处理该按钮的 scancode_waitmsg 事件.
+func (r *Router) ScanCodeWaitMsgButton(name, key string, handler core.Handler) Button {
+	return r.button(ButtonTypeScanCodeWaitMsg, name, key, handler)
+}
+
+// ScanCodeWaitMsgButtonFunc 同 ScanCodeWaitMsgButton.
+func (r *Router) ScanCodeWaitMsgButtonFunc(name, key string, handler func(*core.Context)) Button {
+	return r.button(ButtonTypeScanCodeWaitMsg, name, key, core.HandlerFunc(handler))
+}
+
+// PicSysPhotoButton 返回 弹出系统拍照发图 类型按钮, 同时注册 handler 处理该按钮的 pic_sysphoto 事件.
+func (r *Router) PicSysPhotoButton(name, key string, handler core.Handler) Button {
+	return r.button(ButtonTypePicSysPhoto, name, key, handler)
+}
+
+// PicSysPhotoButtonFunc 同 PicSysPhotoButton.
+func (r *Router) PicSysPhotoButtonFunc(name, key string, handler func(*core.Context)) Button {
+	return r.button(ButtonTypePicSysPhoto, name, key, core.HandlerFunc(handler))
+}
+
+// PicPhotoOrAlbumButton 返回 弹出拍照或者相册发图 类型按钮, 同时注册 handler 处理该按钮的 pic_photo_or_album 事件.
+func (r *Router) PicPhotoOrAlbumButton(name, key string, handler core.Handler) Button {
+	return r.button(ButtonTypePicPhotoOrAlbum, name, key, handler)
+}
+
+// PicPhotoOrAlbumButtonFunc 同 PicPhotoOrAlbumButton.
+func (r *Router) PicPhotoOrAlbumButtonFunc(name, key string, handler func(*core.Context)) Button {
+	return r.button(ButtonTypePicPhotoOrAlbum, name, key, core.HandlerFunc(handler))
+}
+
+// PicWeixinButton 返回 弹出微信相册发图器 类型按钮, 同时注册 handler 处理该按钮的 pic_weixin 事件.
+func (r *Router) PicWeixinButton(name, key string, handler core.Handler) Button {
+	return r.button(ButtonTypePicWeixin, name, key, handler)
+}
+
+// PicWeixinButtonFunc 同 PicWeixinButton.
+func (r *Router) PicWeixinButtonFunc(name, key string, handler func(*core.Context)) Button {
+	return r.button(ButtonTypePicWeixin, name, key, core.HandlerFunc(handler))
+}
+
+// LocationSelectButton 返回 弹出地理位置选择器 类型按钮, 同时注册 handler 处理该按钮的 location_select 事件.
+func (r *Router) LocationSelectButton(name, key string, handler core.Handler) Button {
+	return r.button(ButtonTypeLocationSelect, name, key, handler)
+}
+
+// LocationSelectButtonFunc 同 LocationSelectButton.
+func (r *Router) LocationSelectButtonFunc(name, key string, handler func(*core.Context)) Button {
+	return r.button(ButtonTypeLocationSelect, name, key, core.HandlerFunc(handler))
+}
+
+// Register 在 mux 上为 Router 中出现的每个事件类型注册 Router 自己, 由 Router 按照 EventKey 分发.
+//
+//	NOTE: 会覆盖 mux 上这些事件类型已经注册的 Handler.
+func (r *Router) Register(mux *core.ServeMux) {
+	for eventType := range r.routes {
+		mux.EventHandle(eventType, r)
+	}
+}
+
+// ServeMsg 实现 core.Handler 接口, 按照 EventType 和 EventKey 分发事件.
+func (r *Router) ServeMsg(ctx *core.Context) {
+	if handler := r.routes[normalizeEventType(ctx.MixedMsg.EventType)][ctx.MixedMsg.EventKey]; handler != nil {
+		handler.ServeMsg(ctx)
+		return
+	}
+	if r.NotFound != nil {
+		r.NotFound.ServeMsg(ctx)
+		return
+	}
+	ctx.NoneResponse()
+}
+
+// Check 检查 menus 中的按钮和 Router 中注册的 Handler 是否一一对应,
+// 如果 menus 中有的按钮没有注册 Handler, 或者 Router 中注册了 Handler 但是 menus 中没有对应的按钮,
+// 则返回包含所有问题的 *core.ValidationError.
+func (r *Router) Check(menus ...*Menu) error {
+	used := make(map[core.EventType]map[string]bool)
+	var problems []string
+	var walk func(buttons []Button, path string)
+	walk = func(buttons []Button, path string) {
+		for i := range buttons {
+			btn := &buttons[i]
+			if len(btn.SubButtons) > 0 {
+				walk(btn.SubButtons, path+btn.Name+"/")
+				continue
+			}
+			eventType, ok := buttonEventTypes[btn.Type]
+			if !ok {
+				continue
+			}
+			eventType = normalizeEventType(eventType)
+			if r.routes[eventType][btn.Key] == nil {
+				problems = append(problems, "button "+path+btn.Name+" ("+btn.Type+" key="+btn.Key+") has no handler")
+				continue
+			}
+			if used[eventType] == nil {
+				used[eventType] = make(map[string]bool)
+			}
+			used[eventType][btn.Key] = true
+		}
+	}
+	for _, m := range menus {
+		walk(m.Buttons, "")
+	}
+
+	var unused []string
+	for eventType, m := range r.routes {
+		for key := range m {
+			if !used[eventType][key] {
+				unused = append(unused, "handler for "+string(eventType)+" key="+key+" has no button")
+			}
+		}
+	}
+	sort.Strings(unused)
+	problems = append(problems, unused...)
+
+	if len(problems) > 0 {
+		return &core.ValidationError{Subject: "menu router", Problems: problems}
+	}
+	return nil
+}
+
+// 和 core.ServeMux 一样, 事件类型不区分大小写.
+func normalizeEventType(eventType core.EventType) core.EventType {
+	return core.EventType(strings.ToLower(string(eventType)))
+}
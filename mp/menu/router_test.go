@@ -0,0 +1,55 @@
+package menu
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+func TestRouter(t *testing.T) {
+	var served []string
+	router := NewRouter()
+	var m Menu
+	m.Buttons = []Button{
+		router.ClickButtonFunc("今日歌曲", "MUSIC", func(ctx *core.Context) { served = append(served, "music") }),
+		{Name: "菜单", SubButtons: []Button{
+			router.ScanCodePushButtonFunc("扫一扫", "SCAN", func(ctx *core.Context) { served = append(served, "scan") }),
+			{Type: ButtonTypeClick, Name: "赞", Key: "GOOD"},
+		}},
+	}
+	router.ClickButtonFunc("旧按钮", "OLD", func(ctx *core.Context) {})
+
+	err := router.Check(&m)
+	verr, ok := err.(*core.ValidationError)
+	if !ok {
+		t.Fatalf("want *core.ValidationError, have %v", err)
+	}
+	want := []string{"button 菜单/赞 (click key=GOOD) has no handler", "handler for click key=OLD has no button"}
+	if verr.Subject != "menu router" || !reflect.DeepEqual(verr.Problems, want) {
+		t.Errorf("have %+v, want problems %q", verr, want)
+	}
+
+	router.Register(core.NewServeMux())
+	for _, msg := range []core.MixedMsg{
+		{MsgHeader: core.MsgHeader{MsgType: "event"}, EventType: EventTypeClick, EventKey: "MUSIC"},
+		{MsgHeader: core.MsgHeader{MsgType: "event"}, EventType: EventTypeScanCodePush, EventKey: "SCAN"},
+		{MsgHeader: core.MsgHeader{MsgType: "event"}, EventType: EventTypeClick, EventKey: "UNKNOWN"},
+	} {
+		msg := msg
+		ctx := &core.Context{ResponseWriter: httptest.NewRecorder(), MixedMsg: &msg}
+		router.ServeMsg(ctx)
+	}
+	if have := strings.Join(served, ","); have != "music,scan" {
+		t.Errorf("have %q, want %q", have, "music,scan")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic for duplicate key")
+		}
+	}()
+	router.ClickButtonFunc("重复", "MUSIC", func(ctx *core.Context) {})
+}
@@ -0,0 +1,200 @@
+package menu
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+// 自定义菜单的限制.
+const (
+	MaxButtonCount         = 3    // 一级菜单最多3个
+	MaxSubButtonCount      = 5    // 每个一级菜单最多包含5个二级菜单
+	MaxButtonNameLength    = 16   // 一级菜单标题不超过16个字节
+	MaxSubButtonNameLength = 60   // 二级菜单标题不超过60个字节
+	MaxKeyLength           = 128  // 菜单KEY值最多128字节
+	MaxURLLength           = 1024 // 网页链接最多1024字节
+)
+
+type validator struct {
+	thirdParty bool
+	problems   []string
+}
+
+func (v *validator) addf(field, format string, args ...interface{}) {
+	v.problems = append(v.problems, field+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &core.ValidationError{Subject: "menu", Problems: v.problems}
+}
+
+// Validate 在调用 Create 或 AddConditionalMenu 之前检查菜单是否满足文档描述的规则, 并一次性返回所有的问题(*core.ValidationError).
+//
+//	NOTE: media_id 和 view_limited 类型的按钮只有第三方平台旗下未微信认证的订阅号可以使用, Validate 认为这两种类型不合法,
+//	如果需要请使用 ValidateThirdParty.
+func (menu *Menu) Validate() error {
+	v := &validator{}
+	v.checkMenu(menu)
+	return v.err()
+}
+
+// ValidateThirdParty 同 Validate, 但是允许 media_id 和 view_limited 类型的按钮.
+func (menu *Menu) ValidateThirdParty() error {
+	v := &validator{thirdParty: true}
+	v.checkMenu(menu)
+	return v.err()
+}
+
+// Validate 检查个性化菜单的匹配规则, 并一次性返回所有的问题(*core.ValidationError).
+func (rule *MatchRule) Validate() error {
+	v := &validator{}
+	v.checkMatchRule("matchrule", rule)
+	return v.err()
+}
+
+func (v *validator) checkMenu(menu *Menu) {
+	switch n := len(menu.Buttons); {
+	case n == 0:
+		v.addf("button", "required")
+	case n > MaxButtonCount:
+		v.addf("button", "too many buttons, max %d, have %d", MaxButtonCount, n)
+	}
+	for i := range menu.Buttons {
+		v.checkButton(fmt.Sprintf("button[%d]", i), &menu.Buttons[i], true)
+	}
+	if menu.MatchRule != nil { // 默认菜单没有匹配规则
+		v.checkMatchRule("matchrule", menu.MatchRule)
+	}
+}
+
+func (v *validator) checkButton(field string, btn *Button, topLevel bool) {
+	maxNameLength := MaxSubButtonNameLength
+	if topLevel {
+		maxNameLength = MaxButtonNameLength
+	}
+	switch {
+	case btn.Name == "":
+		v.addf(field+".name", "required")
+	case len(btn.Name) > maxNameLength:
+		v.addf(field+".name", "too long, max %d bytes, have %d", maxNameLength, len(btn.Name))
+	}
+
+	if len(btn.SubButtons) > 0 {
+		if !topLevel {
+			v.addf(field+".sub_button", "sub button can not have sub_button")
+			return
+		}
+		if btn.Type != "" {
+			v.addf(field+".type", "must be empty for button with sub_button")
+		}
+		if n := len(btn.SubButtons); n > MaxSubButtonCount {
+			v.addf(field+".sub_button", "too many sub buttons, max %d, have %d", MaxSubButtonCount, n)
+		}
+		for i := range btn.SubButtons {
+			v.checkButton(fmt.Sprintf("%s.sub_button[%d]", field, i), &btn.SubButtons[i], false)
+		}
+		return
+	}
+
+	switch btn.Type {
+	case ButtonTypeClick, ButtonTypeScanCodePush, ButtonTypeScanCodeWaitMsg,
+		ButtonTypePicSysPhoto, ButtonTypePicPhotoOrAlbum, ButtonTypePicWeixin, ButtonTypeLocationSelect:
+		switch {
+		case btn.Key == "":
+			v.addf(field+".key", "required for %s button", btn.Type)
+		case len(btn.Key) > MaxKeyLength:
+			v.addf(field+".key", "too long, max %d bytes, have %d", MaxKeyLength, len(btn.Key))
+		}
+	case ButtonTypeView:
+		v.checkURL(field+".url", btn.URL)
+	case ButtonTypeMiniProgram:
+		if btn.AppId == "" {
+			v.addf(field+".appid", "required for miniprogram button")
+		}
+		if btn.PagePath == "" {
+			v.addf(field+".pagepath", "required for miniprogram button")
+		}
+		// 不支持小程序的老版本客户端将打开本url
+		v.checkURL(field+".url", btn.URL)
+	case ButtonTypeMediaId, ButtonTypeViewLimited:
+		if !v.thirdParty {
+			v.addf(field+".type", "%s button is only available for third-party platform", btn.Type)
+		}
+		if btn.MediaId == "" {
+			v.addf(field+".media_id", "required for %s button", btn.Type)
+		}
+	case ButtonTypeText, ButtonTypeImage, ButtonTypePhoto, ButtonTypeVideo, ButtonTypeVoice:
+		v.addf(field+".type", "%s button can not be created by API", btn.Type)
+	case "":
+		v.addf(field+".type", "required")
+	default:
+		v.addf(field+".type", "unknown type %q", btn.Type)
+	}
+}
+
+func (v *validator) checkURL(field, s string) {
+	if s == "" {
+		v.addf(field, "required")
+		return
+	}
+	if len(s) > MaxURLLength {
+		v.addf(field, "too long, max %d bytes, have %d", MaxURLLength, len(s))
+		return
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addf(field, "invalid url %q, must start with http:// or https://", s)
+	}
+}
+
+// 个性化菜单支持的语言
+var matchRuleLanguages = map[string]bool{
+	"zh_CN": true, "zh_TW": true, "zh_HK": true, "en": true, "id": true, "ms": true, "es": true,
+	"ko": true, "it": true, "ja": true, "pl": true, "pt": true, "ru": true, "th": true,
+	"vi": true, "ar": true, "hi": true, "he": true, "tr": true, "de": true, "fr": true,
+}
+
+func (v *validator) checkMatchRule(field string, rule *MatchRule) {
+	if rule == nil {
+		v.addf(field, "required")
+		return
+	}
+	if *rule == (MatchRule{}) {
+		v.addf(field, "at least one rule is required")
+		return
+	}
+	checkNumber := func(name, value string) {
+		if value == "" {
+			return
+		}
+		if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+			v.addf(field+"."+name, "must be a number, have %q", value)
+		}
+	}
+	checkNumber("tag_id", rule.TagId)
+	checkNumber("group_id", rule.GroupId)
+	if rule.Sex != "" && rule.Sex != "1" && rule.Sex != "2" {
+		v.addf(field+".sex", "must be 1(男) or 2(女), have %q", rule.Sex)
+	}
+	switch rule.ClientPlatformType {
+	case "", "1", "2", "3":
+	default:
+		v.addf(field+".client_platform_type", "must be 1(IOS), 2(Android) or 3(Others), have %q", rule.ClientPlatformType)
+	}
+	if rule.Language != "" && !matchRuleLanguages[rule.Language] {
+		v.addf(field+".language", "unsupported language %q", rule.Language)
+	}
+	// 地区信息从大到小验证, 小的可以不填, 即若填写了省份信息, 则国家信息也必填并且匹配
+	if rule.Province != "" && rule.Country == "" {
+		v.addf(field+".country", "required when province is set")
+	}
+	if rule.City != "" && rule.Province == "" {
+		v.addf(field+".province", "required when city is set")
+	}
+}
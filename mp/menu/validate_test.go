@@ -0,0 +1,66 @@
+package menu
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+func TestMenuValidate(t *testing.T) {
+	var menu Menu
+	menu.Buttons = make([]Button, 3)
+	menu.Buttons[0].SetAsClickButton("今日歌曲", "V1001_TODAY_MUSIC")
+	menu.Buttons[1].SetAsViewButton("搜索", "http://www.soso.com/")
+	subButtons := make([]Button, 2)
+	subButtons[0].SetAsMiniProgramButton("小程序", "wx286b93c14bbf93aa", "pages/lunar/index", "http://mp.weixin.qq.com")
+	subButtons[1].SetAsLocationSelectButton("发送位置", "rselfmenu_2_0")
+	menu.Buttons[2].SetAsSubMenuButton("菜单", subButtons)
+	menu.MatchRule = &MatchRule{TagId: "2", Sex: "1", Country: "中国", Province: "广东"}
+	if err := menu.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	menu.Buttons = append(menu.Buttons, Button{})
+	menu.Buttons[0].SetAsClickButton("一二三四五六", "")
+	menu.Buttons[1].SetAsViewButton("搜索", "www.soso.com")
+	subButtons[0].PagePath = ""
+	subButtons[1].SetAsMediaIdButton("图片", "MEDIA_ID")
+	menu.MatchRule = &MatchRule{Sex: "0", City: "广州"}
+
+	err := menu.Validate()
+	verr, ok := err.(*core.ValidationError)
+	if !ok {
+		t.Fatalf("want *core.ValidationError, have %v", err)
+	}
+	want := []string{
+		"button: too many buttons, max 3, have 4",
+		"button[0].name: too long, max 16 bytes, have 18",
+		"button[0].key: required for click button",
+		`button[1].url: invalid url "www.soso.com", must start with http:// or https://`,
+		"button[2].sub_button[0].pagepath: required for miniprogram button",
+		"button[2].sub_button[1].type: media_id button is only available for third-party platform",
+		"button[3].name: required",
+		"button[3].type: required",
+		`matchrule.sex: must be 1(男) or 2(女), have "0"`,
+		"matchrule.province: required when city is set",
+	}
+	if !reflect.DeepEqual(verr.Problems, want) {
+		t.Errorf("have:\n%q\nwant:\n%q", verr.Problems, want)
+	}
+
+	menu.Buttons = menu.Buttons[2:3]
+	subButtons[0].PagePath = "pages/lunar/index"
+	menu.MatchRule = nil
+	if err = menu.ValidateThirdParty(); err != nil {
+		t.Errorf("ValidateThirdParty: %v", err)
+	}
+}
+
+func TestMatchRuleValidateNil(t *testing.T) {
+	var rule *MatchRule
+	err := rule.Validate()
+	if err == nil || err.Error() != "invalid menu: matchrule: required" {
+		t.Errorf("have %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package custom
+
+import (
+	"github.com/chanxuehong/wechat/mp/media"
+)
+
+// NewImageFromCache 通过 cache 上传(或者复用没有过期的)图片 filepath, 然后创建图片消息.
+func NewImageFromCache(cache *media.Cache, toUser, filepath, kfAccount string) (image *Image, err error) {
+	info, err := cache.Upload(media.MediaTypeImage, filepath)
+	if err != nil {
+		return
+	}
+	image = NewImage(toUser, info.MediaId, kfAccount)
+	return
+}
+
+// NewVoiceFromCache 通过 cache 上传(或者复用没有过期的)语音 filepath, 然后创建语音消息.
+func NewVoiceFromCache(cache *media.Cache, toUser, filepath, kfAccount string) (voice *Voice, err error) {
+	info, err := cache.Upload(media.MediaTypeVoice, filepath)
+	if err != nil {
+		return
+	}
+	voice = NewVoice(toUser, info.MediaId, kfAccount)
+	return
+}
+
+// NewVideoFromCache 通过 cache 上传(或者复用没有过期的)视频 videoFilepath 和缩略图 thumbFilepath, 然后创建视频消息.
+//
+//	thumbFilepath 可以为空, 表示没有缩略图.
+func NewVideoFromCache(cache *media.Cache, toUser, videoFilepath, thumbFilepath, title, description, kfAccount string) (video *Video, err error) {
+	info, err := cache.Upload(media.MediaTypeVideo, videoFilepath)
+	if err != nil {
+		return
+	}
+	var thumbMediaId string
+	if thumbFilepath != "" {
+		thumbInfo, err := cache.Upload(media.MediaTypeThumb, thumbFilepath)
+		if err != nil {
+			return nil, err
+		}
+		thumbMediaId = thumbInfo.MediaId
+	}
+	video = NewVideo(toUser, info.MediaId, thumbMediaId, title, description, kfAccount)
+	return
+}
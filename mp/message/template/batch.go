@@ -0,0 +1,294 @@
+package template
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+const (
+	ErrCodeSystemBusy      = -1    // 系统繁忙, 可以重试
+	ErrCodeAPIFreqOutLimit = 45009 // 接口调用超过限制, 可以稍后重试
+)
+
+const (
+	DefaultPendingTTL = time.Hour   // 默认等待 TEMPLATESENDJOBFINISH 事件的最长时间
+	earlyEventTTL     = time.Minute // 早于 Send 返回的事件的缓存时间
+	pruneInterval     = time.Minute // 清理过期的 pending 和 early 的最小间隔
+)
+
+var timeNow = time.Now
+
+const (
+	SendStatusSent   = "sent"   // Send 成功, 等待 TEMPLATESENDJOBFINISH 事件
+	SendStatusFailed = "failed" // Send 失败, 参考 SendResult.Err
+)
+
+// SendResult 是 BatchSender 发送一条模板消息的结果.
+type SendResult struct {
+	ToUser  string
+	MsgId   int64
+	Err     error  // Send 的错误, 重试之后仍然失败的最后一个错误
+	Retries int    // 重试次数
+	Status  string // SendStatusSent, SendStatusFailed, 收到 TEMPLATESENDJOBFINISH 事件后为事件的 Status(TemplateSendStatus*)
+}
+
+// Delivered 判断是否已经送达成功.
+func (result *SendResult) Delivered() bool {
+	return result.Status == TemplateSendStatusSuccess
+}
+
+// BatchStats 是 BatchSender.Send 的统计信息.
+type BatchStats struct {
+	Total  int // 总数
+	Sent   int // Send 成功数
+	Failed int // Send 失败数
+}
+
+var _ core.Handler = (*BatchSender)(nil)
+
+// BatchSender 批量发送模板消息, 支持并发数和 QPS 限制, 自动重试临时性的错误,
+// 并通过 TEMPLATESENDJOBFINISH 事件跟踪每条消息的送达状态.
+//
+//	用法:
+//	1. 把 BatchSender 注册为 TEMPLATESENDJOBFINISH 事件的 Handler:
+//	       mux.EventHandle(template.EventTypeTemplateSendJobFinish, sender)
+//	2. 调用 BatchSender.Send 发送消息, 每条消息的结果通过 OnResult 回调, 送达状态通过 OnStatus 回调.
+type BatchSender struct {
+	Client *core.Client // 必须指定
+
+	Concurrency   int           // 并发数, 如果 <= 0 则默认为 8
+	QPS           int           // 每秒最多调用多少次 Send 接口, 如果 <= 0 则不限制
+	MaxRetries    int           // 临时性错误的最大重试次数, 如果 < 0 则不重试, 0 则默认为 2
+	RetryInterval time.Duration // 重试的间隔, 如果 <= 0 则默认为 1 秒, 每次重试间隔加倍
+	PendingTTL    time.Duration // 等待 TEMPLATESENDJOBFINISH 事件的最长时间, 超时的消息不再跟踪, 如果 <= 0 则默认为 DefaultPendingTTL
+
+	// OnResult 每条消息 Send 完成(成功或者失败)后调用, 可以并发调用, 可以为 nil.
+	OnResult func(*SendResult)
+	// OnStatus 收到消息的 TEMPLATESENDJOBFINISH 事件后调用, 可以并发调用, 可以为 nil.
+	OnStatus func(*SendResult)
+
+	mu        sync.Mutex
+	pending   map[int64]*pendingResult // msgid -> result, 等待 TEMPLATESENDJOBFINISH 事件
+	early     map[int64]*earlyEvent    // msgid -> event, 还没有找到对应消息的 TEMPLATESENDJOBFINISH 事件
+	lastPrune time.Time
+}
+
+type pendingResult struct {
+	result  SendResult
+	expires time.Time
+}
+
+type earlyEvent struct {
+	status  string
+	expires time.Time
+}
+
+// Send 从 messages 读取模板消息并发送, 直到 messages 被关闭并且所有消息都发送完毕才返回.
+func (sender *BatchSender) Send(messages <-chan *TemplateMessage2) (stats BatchStats) {
+	if sender.Client == nil {
+		panic("nil BatchSender.Client")
+	}
+	concurrency := sender.Concurrency
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+
+	var limiter <-chan time.Time
+	if sender.QPS > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(sender.QPS))
+		defer ticker.Stop()
+		limiter = ticker.C
+	}
+
+	var (
+		wg      sync.WaitGroup
+		statsMu sync.Mutex
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range messages {
+				result := sender.send(msg, limiter)
+
+				statsMu.Lock()
+				stats.Total++
+				if result.Err != nil {
+					stats.Failed++
+				} else {
+					stats.Sent++
+				}
+				statsMu.Unlock()
+
+				// 先登记再回调 OnResult, 如果事件已经先到了, 在 OnResult 之后回调 OnStatus
+				var status *SendResult
+				if result.Err == nil {
+					status = sender.register(result)
+				}
+				if sender.OnResult != nil {
+					sender.OnResult(result)
+				}
+				if status != nil && sender.OnStatus != nil {
+					sender.OnStatus(status)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return
+}
+
+func (sender *BatchSender) send(msg *TemplateMessage2, limiter <-chan time.Time) *SendResult {
+	maxRetries := sender.MaxRetries
+	switch {
+	case maxRetries < 0:
+		maxRetries = 0
+	case maxRetries == 0:
+		maxRetries = 2
+	}
+	interval := sender.RetryInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	result := &SendResult{ToUser: msg.ToUser}
+	for {
+		if limiter != nil {
+			<-limiter
+		}
+		msgid, err := Send(sender.Client, msg)
+		if err == nil {
+			result.MsgId = msgid
+			result.Err = nil
+			result.Status = SendStatusSent
+			break
+		}
+		result.Err = err
+		result.Status = SendStatusFailed
+		if result.Retries >= maxRetries || !isTemporaryError(err) {
+			break
+		}
+		result.Retries++
+		time.Sleep(interval)
+		interval *= 2
+	}
+
+	return result
+}
+
+// register 登记 Send 成功的消息等待 TEMPLATESENDJOBFINISH 事件,
+// 如果该消息的事件已经先到了, 返回更新了 Status 的 result 副本.
+func (sender *BatchSender) register(result *SendResult) *SendResult {
+	ttl := sender.PendingTTL
+	if ttl <= 0 {
+		ttl = DefaultPendingTTL
+	}
+
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	now := timeNow()
+	sender.prune(now)
+	if event := sender.early[result.MsgId]; event != nil {
+		delete(sender.early, result.MsgId)
+		resultCopy := *result
+		resultCopy.Status = event.status
+		return &resultCopy
+	}
+	if sender.pending == nil {
+		sender.pending = make(map[int64]*pendingResult)
+	}
+	sender.pending[result.MsgId] = &pendingResult{result: *result, expires: now.Add(ttl)}
+	return nil
+}
+
+// prune 删除过期的 pending 和 early, 调用者需要持有 sender.mu.
+func (sender *BatchSender) prune(now time.Time) {
+	if now.Sub(sender.lastPrune) < pruneInterval {
+		return
+	}
+	sender.lastPrune = now
+	for msgid, p := range sender.pending {
+		if now.After(p.expires) {
+			delete(sender.pending, msgid)
+		}
+	}
+	for msgid, event := range sender.early {
+		if now.After(event.expires) {
+			delete(sender.early, msgid)
+		}
+	}
+}
+
+// isTemporaryError 判断 err 是否是可以重试的错误:
+// 系统繁忙, 接口调用超过限制, 以及连接失败(请求还没有发出, 重试不会导致重复发送).
+func isTemporaryError(err error) bool {
+	if e, ok := err.(*core.Error); ok {
+		switch e.ErrCode {
+		case ErrCodeSystemBusy, ErrCodeAPIFreqOutLimit:
+			return true
+		}
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial"
+	}
+	return false
+}
+
+// HandleEvent 根据 TEMPLATESENDJOBFINISH 事件更新对应消息的送达状态, 找到对应的消息返回 true.
+//
+//	事件可能早于 Send 接口返回 msgid 到达, 所以找不到对应消息的事件会缓存一分钟,
+//	期间 Send 返回了对应的 msgid 会在 OnResult 之后回调 OnStatus, 这种情况 HandleEvent 返回 false.
+func (sender *BatchSender) HandleEvent(event *TemplateSendJobFinishEvent) bool {
+	sender.mu.Lock()
+	now := timeNow()
+	sender.prune(now)
+	p := sender.pending[event.MsgId]
+	if p == nil {
+		if sender.early == nil {
+			sender.early = make(map[int64]*earlyEvent)
+		}
+		sender.early[event.MsgId] = &earlyEvent{status: event.Status, expires: now.Add(earlyEventTTL)}
+		sender.mu.Unlock()
+		return false
+	}
+	delete(sender.pending, event.MsgId)
+	sender.mu.Unlock()
+
+	if sender.OnStatus != nil {
+		result := p.result
+		result.Status = event.Status
+		sender.OnStatus(&result)
+	}
+	return true
+}
+
+// ServeMsg 实现 core.Handler 接口, 处理 TEMPLATESENDJOBFINISH 事件.
+func (sender *BatchSender) ServeMsg(ctx *core.Context) {
+	if ctx.MixedMsg.EventType == EventTypeTemplateSendJobFinish {
+		sender.HandleEvent(GetTemplateSendJobFinishEvent(ctx.MixedMsg))
+	}
+	ctx.NoneResponse()
+}
+
+// Pending 返回已经 Send 成功但是还没有收到 TEMPLATESENDJOBFINISH 事件(并且没有超过 PendingTTL)的消息结果的副本.
+func (sender *BatchSender) Pending() []SendResult {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	now := timeNow()
+	results := make([]SendResult, 0, len(sender.pending))
+	for _, p := range sender.pending {
+		if now.After(p.expires) {
+			continue
+		}
+		results = append(results, p.result)
+	}
+	return results
+}
@@ -0,0 +1,214 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+// newSendServer 模拟模板消息发送接口, msgid 为 touser 的数字部分(比如 "user7" 的 msgid 为 7);
+// "busy" 开头的用户第一次返回系统繁忙, "bad" 开头的用户返回不能重试的错误.
+func newSendServer(t *testing.T, calls map[string]int, maxInFlight *int) *httptest.Server {
+	var (
+		mu       sync.Mutex
+		inFlight int
+	)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg TemplateMessage2
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("Decode: %v", err)
+			return
+		}
+		mu.Lock()
+		calls[msg.ToUser]++
+		n := calls[msg.ToUser]
+		inFlight++
+		if inFlight > *maxInFlight {
+			*maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		var msgid int64
+		fmt.Sscanf(strings.TrimLeft(msg.ToUser, "abcdefghijklmnopqrstuvwxyz"), "%d", &msgid)
+		switch {
+		case strings.HasPrefix(msg.ToUser, "busy") && n == 1:
+			io.WriteString(w, `{"errcode":-1,"errmsg":"system error"}`)
+		case strings.HasPrefix(msg.ToUser, "bad"):
+			io.WriteString(w, `{"errcode":40003,"errmsg":"invalid openid"}`)
+		default:
+			fmt.Fprintf(w, `{"errcode":0,"errmsg":"ok","msgid":%d}`, msgid)
+		}
+	}))
+}
+
+func sendAll(sender *BatchSender, users ...string) BatchStats {
+	messages := make(chan *TemplateMessage2, len(users))
+	for _, user := range users {
+		messages <- &TemplateMessage2{ToUser: user, TemplateId: "TEMPLATE_ID"}
+	}
+	close(messages)
+	return sender.Send(messages)
+}
+
+func TestBatchSenderConcurrency(t *testing.T) {
+	calls := make(map[string]int)
+	var maxInFlight int
+	srv := newSendServer(t, calls, &maxInFlight)
+	defer srv.Close()
+
+	var users []string
+	for i := 1; i <= 20; i++ {
+		users = append(users, fmt.Sprintf("user%d", i))
+	}
+	sender := &BatchSender{Client: testutil.NewClient(srv.URL), Concurrency: 3, QPS: 100}
+	start := time.Now()
+	stats := sendAll(sender, users...)
+	elapsed := time.Since(start)
+
+	if stats != (BatchStats{Total: 20, Sent: 20}) {
+		t.Errorf("stats = %+v", stats)
+	}
+	if maxInFlight > 3 {
+		t.Errorf("max in flight = %d, want <= 3", maxInFlight)
+	}
+	if elapsed < 190*time.Millisecond { // 20 次调用, 每次间隔 10ms
+		t.Errorf("elapsed = %v, QPS limit not applied", elapsed)
+	}
+	if n := len(sender.Pending()); n != 20 {
+		t.Errorf("len(Pending()) = %d, want 20", n)
+	}
+}
+
+func TestBatchSenderRetry(t *testing.T) {
+	calls := make(map[string]int)
+	var maxInFlight int
+	srv := newSendServer(t, calls, &maxInFlight)
+	defer srv.Close()
+
+	var (
+		mu      sync.Mutex
+		results = make(map[string]*SendResult)
+	)
+	sender := &BatchSender{
+		Client:        testutil.NewClient(srv.URL),
+		RetryInterval: time.Millisecond,
+		OnResult: func(result *SendResult) {
+			mu.Lock()
+			results[result.ToUser] = result
+			mu.Unlock()
+		},
+	}
+	stats := sendAll(sender, "busy1", "bad2")
+	if stats != (BatchStats{Total: 2, Sent: 1, Failed: 1}) {
+		t.Errorf("stats = %+v", stats)
+	}
+	if r := results["busy1"]; r.Err != nil || r.Retries != 1 || r.MsgId != 1 || calls["busy1"] != 2 {
+		t.Errorf("busy1: result = %+v, calls = %d", r, calls["busy1"])
+	}
+	if r := results["bad2"]; r.Err == nil || r.Retries != 0 || r.Status != SendStatusFailed || calls["bad2"] != 1 {
+		t.Errorf("bad2: result = %+v, calls = %d", r, calls["bad2"])
+	}
+}
+
+func TestIsTemporaryError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&core.Error{ErrCode: ErrCodeSystemBusy}, true},
+		{&core.Error{ErrCode: ErrCodeAPIFreqOutLimit}, true},
+		{&core.Error{ErrCode: 40003}, false},
+		{&url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}}, true},
+		{&url.Error{Op: "Post", Err: &net.OpError{Op: "read", Err: fmt.Errorf("connection reset by peer")}}, false},
+		{&url.Error{Op: "Post", Err: io.ErrUnexpectedEOF}, false},
+	}
+	for _, tt := range tests {
+		if have := isTemporaryError(tt.err); have != tt.want {
+			t.Errorf("isTemporaryError(%v) = %v, want %v", tt.err, have, tt.want)
+		}
+	}
+}
+
+func TestBatchSenderHandleEvent(t *testing.T) {
+	calls := make(map[string]int)
+	var maxInFlight int
+	srv := newSendServer(t, calls, &maxInFlight)
+	defer srv.Close()
+
+	var (
+		mu       sync.Mutex
+		statuses = make(map[int64]string)
+	)
+	sender := &BatchSender{
+		Client: testutil.NewClient(srv.URL),
+		OnStatus: func(result *SendResult) {
+			mu.Lock()
+			statuses[result.MsgId] = result.Status
+			mu.Unlock()
+		},
+	}
+
+	// msgid 2 的事件早于 Send 返回
+	if sender.HandleEvent(&TemplateSendJobFinishEvent{MsgId: 2, Status: TemplateSendStatusFailedUserBlock}) {
+		t.Error("HandleEvent(2) before Send = true")
+	}
+	sendAll(sender, "user1", "user2")
+	if statuses[2] != TemplateSendStatusFailedUserBlock {
+		t.Errorf("status of early event = %q", statuses[2])
+	}
+
+	pending := sender.Pending()
+	if len(pending) != 1 || pending[0].MsgId != 1 {
+		t.Fatalf("Pending() = %+v", pending)
+	}
+	pending[0].Status = "modified"
+	if sender.Pending()[0].Status != SendStatusSent {
+		t.Error("Pending() returns internal results")
+	}
+
+	if !sender.HandleEvent(&TemplateSendJobFinishEvent{MsgId: 1, Status: TemplateSendStatusSuccess}) {
+		t.Error("HandleEvent(1) = false")
+	}
+	if statuses[1] != TemplateSendStatusSuccess || len(sender.Pending()) != 0 {
+		t.Errorf("statuses = %v, Pending() = %+v", statuses, sender.Pending())
+	}
+	if sender.HandleEvent(&TemplateSendJobFinishEvent{MsgId: 1, Status: TemplateSendStatusSuccess}) {
+		t.Error("HandleEvent(1) twice = true")
+	}
+}
+
+func TestBatchSenderPendingTTL(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	sender := &BatchSender{PendingTTL: 10 * time.Minute}
+	sender.register(&SendResult{MsgId: 1, Status: SendStatusSent})
+	sender.HandleEvent(&TemplateSendJobFinishEvent{MsgId: 100})
+
+	now = now.Add(11 * time.Minute)
+	if n := len(sender.Pending()); n != 0 {
+		t.Errorf("len(Pending()) = %d after PendingTTL", n)
+	}
+	sender.register(&SendResult{MsgId: 2, Status: SendStatusSent})
+	if len(sender.pending) != 1 || len(sender.early) != 0 {
+		t.Errorf("expired entries not pruned: pending = %d, early = %d", len(sender.pending), len(sender.early))
+	}
+}
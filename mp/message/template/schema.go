@@ -0,0 +1,188 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+// Keyword 是模板内容中一个 {{NAME.DATA}} 占位符.
+type Keyword struct {
+	Name      string // 占位符的名称, 比如 first, keyword1, thing2, remark
+	Required  bool   // 是否必须有值
+	MaxLength int    // 值的最大字符(rune)数, 0 表示不限制
+}
+
+// Schema 是从模板内容解析出来的模板数据结构, 用于在发送之前校验模板数据.
+type Schema struct {
+	TemplateId string
+	Title      string
+	Keywords   []Keyword // 按照在模板内容中出现的顺序排列
+}
+
+var keywordRegexp = regexp.MustCompile(`\{\s*\{\s*([A-Za-z0-9_]+)\.DATA\s*\}\s*\}`)
+
+// keywordMaxLength 是新版模板(类目模板)各类关键词的长度限制, key 为去掉末尾数字后的关键词名称.
+var keywordMaxLength = map[string]int{
+	"thing":            20,
+	"number":           32,
+	"letter":           32,
+	"symbol":           5,
+	"character_string": 32,
+	"time":             32,
+	"date":             32,
+	"amount":           32,
+	"phone_number":     17,
+	"car_number":       8,
+	"name":             10,
+	"phrase":           5,
+	"const":            20,
+}
+
+// ParseSchema 解析模板内容中的 {{NAME.DATA}} 占位符, 返回对应的 Schema.
+//
+//	解析出来的 Keyword 默认都是必须的, 长度限制按照新版模板的关键词类型设置, 调用者可以根据需要修改.
+func ParseSchema(tpl *Template) *Schema {
+	schema := &Schema{
+		TemplateId: tpl.TemplateId,
+		Title:      tpl.Title,
+	}
+	seen := make(map[string]bool)
+	for _, match := range keywordRegexp.FindAllStringSubmatch(tpl.Content, -1) {
+		name := match[1]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		schema.Keywords = append(schema.Keywords, Keyword{
+			Name:      name,
+			Required:  true,
+			MaxLength: keywordMaxLength[strings.TrimRight(name, "0123456789")],
+		})
+	}
+	return schema
+}
+
+// GetAllSchema 获取模板列表并解析每个模板的 Schema, 返回的 map 的 key 为 TemplateId.
+func GetAllSchema(clt *core.Client) (schemas map[string]*Schema, err error) {
+	templateList, err := GetAllPrivateTemplate(clt)
+	if err != nil {
+		return
+	}
+	schemas = make(map[string]*Schema, len(templateList))
+	for i := range templateList {
+		schemas[templateList[i].TemplateId] = ParseSchema(&templateList[i])
+	}
+	return
+}
+
+// Keyword 返回名称为 name 的 Keyword, 没有找到返回 nil.
+func (schema *Schema) Keyword(name string) *Keyword {
+	for i := range schema.Keywords {
+		if schema.Keywords[i].Name == name {
+			return &schema.Keywords[i]
+		}
+	}
+	return nil
+}
+
+// Validate 校验模板数据 data 是否满足 Schema, 返回所有的错误.
+func (schema *Schema) Validate(data map[string]DataItem) error {
+	var problems []string
+	for _, keyword := range schema.Keywords {
+		item, ok := data[keyword.Name]
+		if !ok || item.Value == "" {
+			if keyword.Required {
+				problems = append(problems, fmt.Sprintf("missing keyword %q", keyword.Name))
+			}
+			continue
+		}
+		if n := utf8.RuneCountInString(item.Value); keyword.MaxLength > 0 && n > keyword.MaxLength {
+			problems = append(problems, fmt.Sprintf("keyword %q too long: %d characters, max %d", keyword.Name, n, keyword.MaxLength))
+		}
+	}
+	var unknown []string
+	for name := range data {
+		if schema.Keyword(name) == nil {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, fmt.Sprintf("unknown keyword %q", name))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return &core.ValidationError{Subject: "template " + schema.TemplateId, Problems: problems}
+}
+
+// MessageBuilder 根据 Schema 构建模板消息.
+type MessageBuilder struct {
+	schema *Schema
+	msg    TemplateMessage2
+	data   map[string]DataItem
+}
+
+// NewMessage 创建一个发送给 toUser 的 MessageBuilder.
+func (schema *Schema) NewMessage(toUser string) *MessageBuilder {
+	return &MessageBuilder{
+		schema: schema,
+		msg: TemplateMessage2{
+			ToUser:     toUser,
+			TemplateId: schema.TemplateId,
+		},
+		data: make(map[string]DataItem, len(schema.Keywords)),
+	}
+}
+
+// Set 设置关键词 name 的值, color 可以为空.
+func (b *MessageBuilder) Set(name, value, color string) *MessageBuilder {
+	b.data[name] = DataItem{Value: value, Color: color}
+	return b
+}
+
+// SetURL 设置用户点击后跳转的URL.
+func (b *MessageBuilder) SetURL(url string) *MessageBuilder {
+	b.msg.URL = url
+	return b
+}
+
+// SetMiniProgram 设置跳小程序所需数据.
+func (b *MessageBuilder) SetMiniProgram(appId, pagePath string) *MessageBuilder {
+	b.msg.MiniProgram = &MiniProgram{
+		AppId:    appId,
+		PagePath: pagePath,
+	}
+	return b
+}
+
+// Build 校验模板数据并返回模板消息, 校验失败返回 *core.ValidationError.
+func (b *MessageBuilder) Build() (msg *TemplateMessage2, err error) {
+	if b.msg.ToUser == "" {
+		return nil, &core.ValidationError{Subject: "template " + b.schema.TemplateId, Problems: []string{"empty touser"}}
+	}
+	if err = b.schema.Validate(b.data); err != nil {
+		return
+	}
+	data := make(map[string]DataItem, len(b.data))
+	for name, item := range b.data {
+		data[name] = item
+	}
+	msgCopy := b.msg
+	msgCopy.Data = data
+	return &msgCopy, nil
+}
+
+// Send 校验并发送模板消息.
+func (b *MessageBuilder) Send(clt *core.Client) (msgid int64, err error) {
+	msg, err := b.Build()
+	if err != nil {
+		return
+	}
+	return Send(clt, msg)
+}
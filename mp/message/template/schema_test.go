@@ -0,0 +1,57 @@
+package template
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chanxuehong/wechat/mp/core"
+)
+
+func TestParseSchema(t *testing.T) {
+	tpl := &Template{
+		TemplateId: "iPk5sOIt5X_flOVKn5GrTFpncEYTojx6ddbt8WYoV5s",
+		Title:      "领取奖金提醒",
+		Content:    "{ {result.DATA} }\n\n领奖金额:{{withdrawMoney.DATA}}\n领奖时间:{{thing2.DATA}}\n{{remark.DATA}}\n{{remark.DATA}}",
+	}
+	schema := ParseSchema(tpl)
+	want := []Keyword{
+		{Name: "result", Required: true},
+		{Name: "withdrawMoney", Required: true},
+		{Name: "thing2", Required: true, MaxLength: 20},
+		{Name: "remark", Required: true},
+	}
+	if !reflect.DeepEqual(schema.Keywords, want) {
+		t.Errorf("have %+v, want %+v", schema.Keywords, want)
+	}
+}
+
+func TestMessageBuilderBuild(t *testing.T) {
+	schema := ParseSchema(&Template{
+		TemplateId: "TEMPLATE_ID",
+		Content:    "{{first.DATA}}\n物品:{{thing1.DATA}}\n{{remark.DATA}}",
+	})
+	schema.Keyword("remark").Required = false
+
+	msg, err := schema.NewMessage("OPENID").
+		Set("first", "您好", "").
+		Set("thing1", "咖啡", "#173177").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.ToUser != "OPENID" || msg.TemplateId != "TEMPLATE_ID" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+
+	_, err = schema.NewMessage("OPENID").
+		Set("thing1", "一二三四五六七八九十一二三四五六七八九十一", "").
+		Set("keyword1", "typo", "").
+		Build()
+	verr, ok := err.(*core.ValidationError)
+	if !ok {
+		t.Fatalf("have %v, want *core.ValidationError", err)
+	}
+	if len(verr.Problems) != 3 { // missing first, thing1 too long, unknown keyword1
+		t.Errorf("have %d problems, want 3: %v", len(verr.Problems), verr)
+	}
+}
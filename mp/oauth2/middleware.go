@@ -0,0 +1,235 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/chanxuehong/wechat/oauth2"
+	"github.com/chanxuehong/wechat/util"
+)
+
+const (
+	ScopeBase     = "snsapi_base"     // 静默授权, 只能获取 openid
+	ScopeUserInfo = "snsapi_userinfo" // 需要用户确认, 可以获取用户的基本信息
+)
+
+var (
+	ErrStateMismatch = errors.New("oauth2 state mismatch")   // 回调的 state 参数和 cookie 里保存的不一致, 可能是 CSRF 攻击
+	ErrAuthDenied    = errors.New("oauth2 authorize denied") // 用户禁止授权
+)
+
+// AuthInfo 是 Middleware 放到 request context 里的授权信息.
+type AuthInfo struct {
+	OpenId  string
+	UnionId string
+	Token   *oauth2.Token
+
+	// 只有 Scope 为 snsapi_userinfo 并且本次请求刚刚完成授权(即授权回调的请求)才有值,
+	// 后续从 TokenStorage 恢复的请求需要的话自己调用 GetUserInfo 获取.
+	UserInfo *UserInfo
+}
+
+type authInfoContextKey struct{}
+
+// NewContext 返回一个带有 info 的 context.Context.
+func NewContext(ctx context.Context, info *AuthInfo) context.Context {
+	return context.WithValue(ctx, authInfoContextKey{}, info)
+}
+
+// FromContext 获取 Middleware 放到 context.Context 里的授权信息.
+func FromContext(ctx context.Context) (info *AuthInfo, ok bool) {
+	info, ok = ctx.Value(authInfoContextKey{}).(*AuthInfo)
+	return
+}
+
+// Middleware 是网页授权的 http 中间件.
+//
+//  1. 如果 TokenStorage 里有可用的 Token(过期则自动刷新), 直接把授权信息放到 request context 里然后调用下一个 handler;
+//
+//  2. 否则生成随机的 state 存放到 cookie, 然后跳转到 AuthCodeURL;
+//
+//  3. 授权回调时校验 state(防 CSRF), 用 code 换取 Token 并存入 TokenStorage, 然后把授权信息放到 request context 里再调用下一个 handler.
+//
+//     下一个 handler 通过 FromContext(r.Context()) 获取授权信息.
+type Middleware struct {
+	Endpoint *Endpoint // 必须指定
+	Scope    string    // 应用授权作用域, ScopeBase 或者 ScopeUserInfo, 如果为空则默认为 ScopeBase
+	Lang     string    // 获取用户信息的语言版本, 参考 GetUserInfo
+
+	// TokenStorage 返回当前请求对应的 oauth2.TokenStorage, 用于在多个请求之间保持授权状态,
+	// 比如 (*oauth2.CookieStore).TokenStorage; 如果为 nil 则每个请求都需要重新授权.
+	TokenStorage func(w http.ResponseWriter, r *http.Request) oauth2.TokenStorage
+
+	// RedirectURI 返回授权后重定向的回调链接地址, 如果为 nil 则默认为当前请求的 URL(去掉 code 和 state 参数).
+	RedirectURI func(r *http.Request) string
+
+	StateCookieName string // 保存 state 的 cookie 名称, 如果为空则默认为 "wechat_oauth2_state"
+	SecureCookie    bool   // state cookie 的 Secure 属性
+
+	// ErrorHandler 处理授权过程中的错误, 如果为 nil 则 ErrStateMismatch 和 ErrAuthDenied 返回 403, 其他错误返回 500.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+	HttpClient *http.Client // 如果 HttpClient == nil 则默认用 util.DefaultHttpClient
+}
+
+const defaultStateCookieName = "wechat_oauth2_state"
+
+// Handler 返回包装了 next 的 http.Handler.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	if m.Endpoint == nil {
+		panic("nil Middleware.Endpoint")
+	}
+	if next == nil {
+		panic("nil next http.Handler")
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.serveHTTP(w, r, next)
+	})
+}
+
+// HandlerFunc 返回包装了 next 的 http.HandlerFunc.
+func (m *Middleware) HandlerFunc(next func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return m.Handler(http.HandlerFunc(next)).ServeHTTP
+}
+
+func (m *Middleware) serveHTTP(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	var storage oauth2.TokenStorage
+	if m.TokenStorage != nil {
+		storage = m.TokenStorage(w, r)
+	}
+	client := &oauth2.Client{
+		Endpoint:     m.Endpoint,
+		TokenStorage: storage,
+		HttpClient:   m.HttpClient,
+	}
+
+	query := r.URL.Query()
+	if state := query.Get("state"); state != "" {
+		if cookie, err := r.Cookie(m.stateCookieName()); err == nil {
+			// 授权回调, state cookie 只能使用一次
+			http.SetCookie(w, m.newStateCookie("", -1))
+
+			if subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+				m.handleError(w, r, ErrStateMismatch)
+				return
+			}
+			code := query.Get("code")
+			if code == "" {
+				m.handleError(w, r, ErrAuthDenied)
+				return
+			}
+			token, err := client.ExchangeToken(code)
+			if err != nil {
+				m.handleError(w, r, err)
+				return
+			}
+			info := &AuthInfo{
+				OpenId:  token.OpenId,
+				UnionId: token.UnionId,
+				Token:   token,
+			}
+			if m.scope() == ScopeUserInfo {
+				userinfo, err := GetUserInfo(token.AccessToken, token.OpenId, m.Lang, m.HttpClient)
+				if err != nil {
+					m.handleError(w, r, err)
+					return
+				}
+				info.UserInfo = userinfo
+				if info.UnionId == "" {
+					info.UnionId = userinfo.UnionId
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), info)))
+			return
+		}
+	}
+
+	if storage != nil {
+		if token, err := client.GetToken(true); err == nil && token.OpenId != "" {
+			info := &AuthInfo{
+				OpenId:  token.OpenId,
+				UnionId: token.UnionId,
+				Token:   token,
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), info)))
+			return
+		}
+	}
+
+	state := util.NonceStr()
+	http.SetCookie(w, m.newStateCookie(state, 10*60))
+	http.Redirect(w, r, AuthCodeURL(m.Endpoint.AppId, m.redirectURI(r), m.scope(), state), http.StatusFound)
+}
+
+func (m *Middleware) scope() string {
+	if m.Scope != "" {
+		return m.Scope
+	}
+	return ScopeBase
+}
+
+func (m *Middleware) stateCookieName() string {
+	if m.StateCookieName != "" {
+		return m.StateCookieName
+	}
+	return defaultStateCookieName
+}
+
+func (m *Middleware) newStateCookie(value string, maxAge int) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     m.stateCookieName(),
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   m.SecureCookie,
+		HttpOnly: true,
+	}
+	if maxAge > 0 {
+		cookie.Expires = time.Now().Add(time.Duration(maxAge) * time.Second)
+	}
+	return cookie
+}
+
+func (m *Middleware) redirectURI(r *http.Request) string {
+	if m.RedirectURI != nil {
+		return m.RedirectURI(r)
+	}
+	return currentURL(r)
+}
+
+// currentURL 返回当前请求的完整 URL, 并去掉 code 和 state 参数.
+func currentURL(r *http.Request) string {
+	u := *r.URL
+	u.Host = r.Host
+	switch {
+	case r.TLS != nil:
+		u.Scheme = "https"
+	case r.Header.Get("X-Forwarded-Proto") == "https":
+		u.Scheme = "https"
+	default:
+		u.Scheme = "http"
+	}
+	query := u.Query()
+	query.Del("code")
+	query.Del("state")
+	u.RawQuery = query.Encode()
+	u.Fragment = ""
+	u.User = nil
+	return u.String()
+}
+
+func (m *Middleware) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if m.ErrorHandler != nil {
+		m.ErrorHandler(w, r, err)
+		return
+	}
+	switch err {
+	case ErrStateMismatch, ErrAuthDenied:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
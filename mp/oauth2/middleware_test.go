@@ -0,0 +1,134 @@
+package oauth2
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+)
+
+func newTestMiddleware(t *testing.T) (m *Middleware, next http.Handler, infos *[]*AuthInfo, closeFn func()) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sns/oauth2/access_token" || r.URL.Query().Get("code") != "CODE" {
+			t.Errorf("unexpected request %s", r.URL)
+			io.WriteString(w, `{"errcode":40029,"errmsg":"invalid code"}`)
+			return
+		}
+		io.WriteString(w, `{"access_token":"ACCESS_TOKEN","expires_in":7200,"refresh_token":"REFRESH_TOKEN","openid":"OPENID","scope":"snsapi_base"}`)
+	}))
+	m = &Middleware{
+		Endpoint:   NewEndpoint("APPID", "APPSECRET"),
+		HttpClient: &http.Client{Transport: testutil.RewriteTransport{Target: srv.URL}},
+	}
+	infos = new([]*AuthInfo)
+	next = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info, _ := FromContext(r.Context())
+		*infos = append(*infos, info)
+	})
+	return m, next, infos, srv.Close
+}
+
+// authorize 请求需要授权的页面, 返回 state cookie.
+func authorize(t *testing.T, h http.Handler) *http.Cookie {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com/page", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusFound)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != defaultStateCookieName || cookies[0].Value == "" {
+		t.Fatalf("unexpected cookies: %v", cookies)
+	}
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := location.Query().Get("state"); state != cookies[0].Value {
+		t.Fatalf("state = %q, cookie = %q", state, cookies[0].Value)
+	}
+	return cookies[0]
+}
+
+func serveCallback(h http.Handler, query string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "http://example.com/page?"+query, nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddlewareCallback(t *testing.T) {
+	m, next, infos, closeFn := newTestMiddleware(t)
+	defer closeFn()
+	h := m.Handler(next)
+
+	cookie := authorize(t, h)
+	w := serveCallback(h, "code=CODE&state="+cookie.Value, cookie)
+	if len(*infos) != 1 || (*infos)[0].OpenId != "OPENID" || (*infos)[0].Token.AccessToken != "ACCESS_TOKEN" {
+		t.Fatalf("status = %d, infos = %v", w.Code, *infos)
+	}
+	// state cookie 只能使用一次
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Errorf("state cookie not cleared: %v", cookies)
+	}
+}
+
+func TestMiddlewareStateMissing(t *testing.T) {
+	m, next, infos, closeFn := newTestMiddleware(t)
+	defer closeFn()
+	h := m.Handler(next)
+
+	// 没有 state cookie 的回调重新授权, 不使用 code
+	w := serveCallback(h, "code=CODE&state=STATE", nil)
+	if w.Code != http.StatusFound || len(*infos) != 0 {
+		t.Fatalf("status = %d, infos = %v", w.Code, *infos)
+	}
+	location, _ := url.Parse(w.Header().Get("Location"))
+	redirectURI := location.Query().Get("redirect_uri")
+	if redirectURI != "http://example.com/page" {
+		t.Errorf("redirect_uri = %q", redirectURI)
+	}
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].Value == "" || cookies[0].Value == "STATE" {
+		t.Errorf("unexpected cookies: %v", cookies)
+	}
+}
+
+func TestMiddlewareStateMismatch(t *testing.T) {
+	m, next, infos, closeFn := newTestMiddleware(t)
+	defer closeFn()
+	var errs []error
+	m.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		errs = append(errs, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+	h := m.Handler(next)
+
+	cookie := authorize(t, h)
+	w := serveCallback(h, "code=CODE&state=OTHER", cookie)
+	if w.Code != http.StatusBadRequest || len(errs) != 1 || errs[0] != ErrStateMismatch {
+		t.Errorf("status = %d, errs = %v", w.Code, errs)
+	}
+	// 校验失败也要清除 state cookie
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Errorf("state cookie not cleared: %v", cookies)
+	}
+
+	serveCallback(h, "state="+cookie.Value, cookie) // 用户拒绝授权
+	if len(errs) != 2 || errs[1] != ErrAuthDenied {
+		t.Errorf("errs = %v", errs)
+	}
+	if len(*infos) != 0 {
+		t.Errorf("next called: %v", *infos)
+	}
+
+	// 默认的 ErrorHandler
+	m.ErrorHandler = nil
+	if w = serveCallback(h, "code=CODE&state=OTHER", cookie); w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,234 @@
+// 粉丝同步.
+//
+//	全量同步: 调用 Syncer.Sync 遍历全部粉丝的 openid, 每 100 个调用一次 user.BatchGet 获取粉丝信息并保存到 Store,
+//	          同时把 Store 中已经不再关注的粉丝标记为取消关注;
+//	增量同步: 把 Syncer 注册为事件中间件, 根据 subscribe/unsubscribe 事件更新 Store:
+//	          mux.UseForEvent(syncer)
+package follower
+
+import (
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/chanxuehong/wechat/mp/core"
+	"github.com/chanxuehong/wechat/mp/message/callback/request"
+	"github.com/chanxuehong/wechat/mp/user"
+)
+
+const (
+	BatchGetMaxCount   = 100 // user.BatchGet 每次最多获取 100 个粉丝的信息
+	DefaultConcurrency = 4   // Syncer.Concurrency 的默认值
+)
+
+// SyncResult 是 Syncer.Sync 的统计结果.
+type SyncResult struct {
+	Total        int // 微信返回的关注该公众号的总用户数
+	Upserted     int // 新增或者更新的粉丝数量
+	Unsubscribed int // 标记为取消关注的粉丝数量
+}
+
+var _ core.Handler = (*Syncer)(nil)
+
+// Syncer 把公众号的粉丝同步到 Store.
+type Syncer struct {
+	clt   *core.Client
+	store Store
+
+	Lang        string // 获取粉丝信息的语言版本, 参考 user.LanguageXXX, 默认为 zh_CN
+	Concurrency int    // 全量同步时并发调用 user.BatchGet 的数量, 默认为 DefaultConcurrency
+
+	// ErrorHandler 处理增量同步的错误, 如果为 nil 则输出到标准错误; 可能在 ServeMsg 启动的 goroutine 中调用.
+	ErrorHandler func(openId string, err error)
+
+	wg sync.WaitGroup // ServeMsg 启动的 subscribe 同步
+}
+
+func NewSyncer(clt *core.Client, store Store) *Syncer {
+	return &Syncer{
+		clt:   clt,
+		store: store,
+	}
+}
+
+// Sync 全量同步粉丝信息, 遇到错误立即停止并返回已经同步的统计结果.
+//
+//	NOTE: Sync 需要在内存中记录全部粉丝的 openid; 同步期间的 subscribe/unsubscribe 事件可能会被 Sync 覆盖,
+//	一般在业务低峰期执行, 或者在 Sync 之后再处理一次期间的事件.
+func (s *Syncer) Sync() (result *SyncResult, err error) {
+	result = &SyncResult{}
+
+	existing, err := s.store.OpenIds()
+	if err != nil {
+		return
+	}
+	seen := make(map[string]struct{}, len(existing))
+
+	iter, err := user.NewUserIterator(s.clt, "")
+	if err != nil {
+		return
+	}
+	result.Total = iter.TotalCount()
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	batches := make(chan []string)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				upserted, unsubscribed, err := s.syncBatch(batch)
+				mu.Lock()
+				result.Upserted += upserted
+				result.Unsubscribed += unsubscribed
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for iter.HasNext() && !failed() {
+		openIds, err := iter.NextPage()
+		if err != nil {
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = err
+			}
+			mu.Unlock()
+			break
+		}
+		for _, openId := range openIds {
+			seen[openId] = struct{}{}
+		}
+		for len(openIds) > 0 && !failed() {
+			n := len(openIds)
+			if n > BatchGetMaxCount {
+				n = BatchGetMaxCount
+			}
+			batches <- openIds[:n]
+			openIds = openIds[n:]
+		}
+	}
+	close(batches)
+	wg.Wait()
+
+	if err = firstErr; err != nil {
+		return
+	}
+
+	var unsubscribed []string
+	for _, openId := range existing {
+		if _, ok := seen[openId]; !ok {
+			unsubscribed = append(unsubscribed, openId)
+		}
+	}
+	if len(unsubscribed) > 0 {
+		if err = s.store.Unsubscribe(unsubscribed); err != nil {
+			return
+		}
+		result.Unsubscribed += len(unsubscribed)
+	}
+	return
+}
+
+// syncBatch 获取 openIds 的粉丝信息并保存, 获取信息时已经取消关注的粉丝标记为取消关注.
+func (s *Syncer) syncBatch(openIds []string) (upserted, unsubscribed int, err error) {
+	list, err := user.BatchGet(s.clt, openIds, s.Lang)
+	if err != nil {
+		return
+	}
+	subscribers := list[:0]
+	var unsubscribers []string
+	for i := range list {
+		if list[i].IsSubscriber == 1 {
+			subscribers = append(subscribers, list[i])
+		} else {
+			unsubscribers = append(unsubscribers, list[i].OpenId)
+		}
+	}
+	if len(subscribers) > 0 {
+		if err = s.store.Upsert(subscribers); err != nil {
+			return
+		}
+		upserted = len(subscribers)
+	}
+	if len(unsubscribers) > 0 {
+		if err = s.store.Unsubscribe(unsubscribers); err != nil {
+			return
+		}
+		unsubscribed = len(unsubscribers)
+	}
+	return
+}
+
+// ServeMsg 实现 core.Handler 接口, 一般作为事件中间件使用, 根据 subscribe/unsubscribe 事件更新 Store, 不回复消息.
+// 和 core.ServeMux 一样, 消息类型和事件类型不区分大小写.
+//
+//	NOTE: 微信服务器在 5 秒内收不到回复会断开连接并重试, 所以 subscribe 事件在新的 goroutine 中调用 Subscribe
+//	(需要调用一次 user.Get), 不阻塞后面的处理器回复消息, 错误交给 ErrorHandler 处理; 程序退出之前可以调用 Wait 等待完成.
+//	关注之后马上取消关注的时候 Subscribe 可能晚于 unsubscribe 事件写入 Store, 下一次 Sync 会修正.
+func (s *Syncer) ServeMsg(ctx *core.Context) {
+	msg := ctx.MixedMsg
+	if strings.ToLower(string(msg.MsgType)) != "event" {
+		return
+	}
+	switch core.EventType(strings.ToLower(string(msg.EventType))) {
+	case request.EventTypeSubscribe:
+		openId := msg.FromUserName
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if err := s.Subscribe(openId); err != nil {
+				s.handleError(openId, err)
+			}
+		}()
+	case request.EventTypeUnsubscribe:
+		if err := s.store.Unsubscribe([]string{msg.FromUserName}); err != nil {
+			s.handleError(msg.FromUserName, err)
+		}
+	}
+}
+
+// Wait 等待 ServeMsg 启动的 subscribe 同步全部完成.
+func (s *Syncer) Wait() {
+	s.wg.Wait()
+}
+
+// Subscribe 获取 openId 的粉丝信息并保存到 Store, 获取信息时已经取消关注则标记为取消关注.
+func (s *Syncer) Subscribe(openId string) error {
+	info, err := user.Get(s.clt, openId, s.Lang)
+	if err != nil {
+		return err
+	}
+	if info.IsSubscriber != 1 {
+		return s.store.Unsubscribe([]string{openId})
+	}
+	return s.store.Upsert([]user.UserInfo{*info})
+}
+
+var errorLogger = log.New(os.Stderr, "[WECHAT_ERROR] ", log.Ldate|log.Ltime|log.Lmicroseconds)
+
+func (s *Syncer) handleError(openId string, err error) {
+	if s.ErrorHandler != nil {
+		s.ErrorHandler(openId, err)
+		return
+	}
+	errorLogger.Printf("follower sync %s: %s", openId, err.Error())
+}
@@ -0,0 +1,155 @@
+package follower
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+	"github.com/chanxuehong/wechat/mp/core"
+	"github.com/chanxuehong/wechat/mp/message/callback/request"
+	"github.com/chanxuehong/wechat/mp/user"
+)
+
+// fakeServer 模拟用户管理接口, 有 total 个粉丝, 每页 pageSize 个 openid, unsubscribed 中的粉丝在获取信息时已经取消关注.
+type fakeServer struct {
+	total        int
+	pageSize     int
+	unsubscribed map[string]bool
+
+	mu             sync.Mutex
+	maxBatchSize   int
+	batchGetCalled int
+}
+
+func openId(i int) string { return fmt.Sprintf("o%04d", i) }
+
+func (srv *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/cgi-bin/user/get":
+		start := 0
+		if next := r.URL.Query().Get("next_openid"); next != "" {
+			fmt.Sscanf(next, "o%d", &start)
+			start++
+		}
+		var openIds []string
+		for i := start; i < srv.total && len(openIds) < srv.pageSize; i++ {
+			openIds = append(openIds, openId(i))
+		}
+		next := ""
+		if len(openIds) > 0 {
+			next = openIds[len(openIds)-1]
+		}
+		b, _ := json.Marshal(openIds)
+		fmt.Fprintf(w, `{"total":%d,"count":%d,"data":{"openid":%s},"next_openid":%q}`, srv.total, len(openIds), b, next)
+	case "/cgi-bin/user/info/batchget":
+		var req struct {
+			UserList []struct {
+				OpenId string `json:"openid"`
+			} `json:"user_list"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		srv.mu.Lock()
+		srv.batchGetCalled++
+		if len(req.UserList) > srv.maxBatchSize {
+			srv.maxBatchSize = len(req.UserList)
+		}
+		srv.mu.Unlock()
+		var list []user.UserInfo
+		for _, item := range req.UserList {
+			list = append(list, srv.userInfo(item.OpenId))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"user_info_list": list})
+	case "/cgi-bin/user/info":
+		json.NewEncoder(w).Encode(srv.userInfo(r.URL.Query().Get("openid")))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (srv *fakeServer) userInfo(openId string) user.UserInfo {
+	if srv.unsubscribed[openId] {
+		return user.UserInfo{OpenId: openId}
+	}
+	return user.UserInfo{IsSubscriber: 1, OpenId: openId, Nickname: "nick-" + openId}
+}
+
+func TestSync(t *testing.T) {
+	fake := &fakeServer{
+		total:        250,
+		pageSize:     120,
+		unsubscribed: map[string]bool{openId(7): true},
+	}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	clt := testutil.NewClient(srv.URL)
+
+	store := NewMemoryStore()
+	store.Upsert([]user.UserInfo{{IsSubscriber: 1, OpenId: "stale"}, {IsSubscriber: 1, OpenId: openId(1)}})
+
+	syncer := NewSyncer(clt, store)
+	syncer.Concurrency = 3
+	result, err := syncer.Sync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SyncResult{Total: 250, Upserted: 249, Unsubscribed: 2}
+	if *result != want {
+		t.Errorf("result = %+v, want %+v", *result, want)
+	}
+	if store.Len() != 249 {
+		t.Errorf("store.Len() = %d", store.Len())
+	}
+	if info, ok := store.Get(openId(1)); !ok || info.Nickname != "nick-"+openId(1) {
+		t.Errorf("store.Get = %+v, %v", info, ok)
+	}
+	if _, ok := store.Get("stale"); ok {
+		t.Error("stale follower not unsubscribed")
+	}
+	// 每页 120 个 openid 分为 100 + 20
+	if fake.maxBatchSize != BatchGetMaxCount || fake.batchGetCalled != 5 {
+		t.Errorf("maxBatchSize = %d, batchGetCalled = %d", fake.maxBatchSize, fake.batchGetCalled)
+	}
+}
+
+func TestServeMsg(t *testing.T) {
+	fake := &fakeServer{unsubscribed: map[string]bool{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	clt := testutil.NewClient(srv.URL)
+
+	store := NewMemoryStore()
+	syncer := NewSyncer(clt, store)
+	syncer.ErrorHandler = func(openId string, err error) { t.Errorf("%s: %v", openId, err) }
+
+	event := func(eventType core.EventType, openId string) *core.Context {
+		msg := &core.MixedMsg{EventType: eventType}
+		msg.MsgType = "event"
+		msg.FromUserName = openId
+		return &core.Context{MixedMsg: msg}
+	}
+
+	syncer.ServeMsg(event(request.EventTypeSubscribe, "o1"))
+	syncer.Wait()
+	if info, ok := store.Get("o1"); !ok || info.Nickname != "nick-o1" {
+		t.Errorf("after subscribe: %+v, %v", info, ok)
+	}
+	syncer.ServeMsg(event(request.EventTypeUnsubscribe, "o1"))
+	if _, ok := store.Get("o1"); ok {
+		t.Error("after unsubscribe: follower still exists")
+	}
+
+	// 事件类型不区分大小写
+	syncer.ServeMsg(event("SUBSCRIBE", "o2"))
+	syncer.Wait()
+	if _, ok := store.Get("o2"); !ok {
+		t.Error("after SUBSCRIBE: follower not found")
+	}
+	syncer.ServeMsg(event("UnSubscribe", "o2"))
+	if _, ok := store.Get("o2"); ok {
+		t.Error("after UnSubscribe: follower still exists")
+	}
+}
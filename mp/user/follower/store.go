@@ -0,0 +1,77 @@
+package follower
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/chanxuehong/wechat/mp/user"
+)
+
+// Store 保存粉丝信息, 必须是并发安全的.
+type Store interface {
+	// Upsert 新增或者更新粉丝信息, 以 OpenId 为主键.
+	Upsert(users []user.UserInfo) error
+	// Unsubscribe 标记 openIds 已经取消关注, 实现可以删除记录, 也可以只修改 IsSubscriber, 不存在的 openid 不是错误.
+	Unsubscribe(openIds []string) error
+	// OpenIds 返回全部已关注的粉丝的 openid.
+	OpenIds() ([]string, error)
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore 是进程内的 Store 实现, 取消关注的粉丝会被删除, 一般用于测试或者粉丝数量不多的公众号.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	users map[string]user.UserInfo
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users: make(map[string]user.UserInfo),
+	}
+}
+
+func (store *MemoryStore) Upsert(users []user.UserInfo) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for i := range users {
+		store.users[users[i].OpenId] = users[i]
+	}
+	return nil
+}
+
+func (store *MemoryStore) Unsubscribe(openIds []string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for _, openId := range openIds {
+		delete(store.users, openId)
+	}
+	return nil
+}
+
+// OpenIds 返回的 openid 是有序的.
+func (store *MemoryStore) OpenIds() ([]string, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	openIds := make([]string, 0, len(store.users))
+	for openId := range store.users {
+		openIds = append(openIds, openId)
+	}
+	sort.Strings(openIds)
+	return openIds, nil
+}
+
+// Get 返回 openId 对应的粉丝信息, 没有找到则 ok 为 false.
+func (store *MemoryStore) Get(openId string) (info user.UserInfo, ok bool) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	info, ok = store.users[openId]
+	return
+}
+
+// Len 返回粉丝的数量.
+func (store *MemoryStore) Len() int {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	return len(store.users)
+}
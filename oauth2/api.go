@@ -46,25 +46,26 @@ func (clt *Client) ExchangeToken(code string) (token *Token, err error) {
 // RefreshToken 刷新 access_token.
 //
 //	NOTE:
-//	1. refreshToken 可以为空.
-//	2. 返回的 token == clt.Token
+//	1. refreshToken 可以为空, 此时刷新当前 Token 的 access_token, 并且同一个用户(openid)并发的刷新请求会合并为一个;
+//	2. 返回的 token == clt.Token;
+//	3. 如果 refresh_token 已经过期或者失效, 返回 ErrReauthorizationRequired, 此时需要用户重新授权.
 func (clt *Client) RefreshToken(refreshToken string) (token *Token, err error) {
 	if clt.Endpoint == nil {
 		err = errors.New("nil Client.Endpoint")
 		return
 	}
 
-	var tk *Token
 	if refreshToken == "" {
+		var tk *Token
 		if tk, err = clt.GetToken(false); err != nil {
 			return
 		}
-		refreshToken = tk.RefreshToken
-	} else {
-		tk = new(Token)
+		return clt.refreshToken(tk)
 	}
 
+	tk := &Token{RefreshToken: refreshToken} // refresh_token 的创建时间未知
 	if err = clt.updateToken(tk, clt.Endpoint.RefreshTokenURL(refreshToken)); err != nil {
+		err = refreshError(err)
 		return
 	}
 	if err = clt.putToken(tk); err != nil {
@@ -74,6 +75,39 @@ func (clt *Client) RefreshToken(refreshToken string) (token *Token, err error) {
 	return
 }
 
+// refreshToken 用 tk.RefreshToken 刷新 tk, 同一个 Endpoint 同一个 refresh_token 并发的刷新请求会合并为一个.
+//
+//	不能只用 openid 作为 key, 不同公众号(或者网站应用)的 openid 可能相同, 而 refreshGroup 是全局的.
+func (clt *Client) refreshToken(tk *Token) (token *Token, err error) {
+	if clt.Endpoint == nil {
+		err = errors.New("nil Client.Endpoint")
+		return
+	}
+	if tk.RefreshTokenExpired() {
+		err = ErrReauthorizationRequired
+		return
+	}
+
+	// 刷新的 URL 包含了 appid 和 refresh_token, 直接作为 key
+	refreshURL := clt.Endpoint.RefreshTokenURL(tk.RefreshToken)
+	tk, err = refreshGroup.Do(refreshURL, func() (*Token, error) {
+		newToken := *tk
+		if err := clt.updateToken(&newToken, refreshURL); err != nil {
+			return nil, refreshError(err)
+		}
+		return &newToken, nil
+	})
+	if err != nil {
+		return
+	}
+	// 每个调用者都要 putToken, 有些 TokenStorage 是和请求绑定的, 比如 CookieStore
+	if err = clt.putToken(tk); err != nil {
+		return
+	}
+	token = tk
+	return
+}
+
 func (clt *Client) updateToken(tk *Token, url string) (err error) {
 	api.DebugPrintGetRequest(url)
 	httpResp, err := clt.httpClient().Get(url)
@@ -115,10 +149,14 @@ func (clt *Client) updateToken(tk *Token, url string) (err error) {
 		return errors.New("expires_in too small: " + strconv.FormatInt(result.ExpiresIn, 10))
 	}
 
+	now := time.Now().Unix()
 	tk.AccessToken = result.AccessToken
-	tk.CreatedAt = time.Now().Unix()
+	tk.CreatedAt = now
 	tk.ExpiresIn = result.ExpiresIn
 	if result.RefreshToken != "" {
+		if result.RefreshToken != tk.RefreshToken { // 新的 refresh_token
+			tk.RefreshTokenCreatedAt = now
+		}
 		tk.RefreshToken = result.RefreshToken
 	}
 	if result.OpenId != "" {
@@ -3,6 +3,7 @@ package oauth2
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/chanxuehong/wechat/util"
 )
@@ -14,9 +15,15 @@ type Client struct {
 	TokenStorage TokenStorage
 	Token        *Token // Client 自动将最新的 Token 更新到此字段, 不管 Token 字段一开始是否被指定!!!
 
+	// RefreshAhead 指定 access_token 过期前多长时间开始主动刷新, 如果 RefreshAhead <= 0 则默认为 DefaultRefreshAhead
+	RefreshAhead time.Duration
+
 	HttpClient *http.Client // 如果 HttpClient == nil 则默认用 util.DefaultHttpClient
 }
 
+// DefaultRefreshAhead 是 Client.RefreshAhead 的默认值.
+const DefaultRefreshAhead = time.Minute * 5
+
 func (clt *Client) httpClient() *http.Client {
 	if clt.HttpClient != nil {
 		return clt.HttpClient
@@ -24,7 +31,18 @@ func (clt *Client) httpClient() *http.Client {
 	return util.DefaultHttpClient
 }
 
-// GetToken 获取 Token, autoRefresh 为 true 时如果 Token 过期则自动刷新.
+func (clt *Client) refreshAhead() time.Duration {
+	if clt.RefreshAhead > 0 {
+		return clt.RefreshAhead
+	}
+	return DefaultRefreshAhead
+}
+
+// GetToken 获取 Token, autoRefresh 为 true 时如果 Token 过期(或者即将过期)则自动刷新.
+//
+//	NOTE:
+//	1. 同一个用户(openid)并发的刷新请求会合并为一个, 所有调用者共享刷新的结果;
+//	2. 如果 refresh_token 已经过期或者失效, 返回 ErrReauthorizationRequired, 此时需要用户重新授权.
 func (clt *Client) GetToken(autoRefresh bool) (tk *Token, err error) {
 	if clt.TokenStorage != nil {
 		if tk, err = clt.TokenStorage.Token(); err != nil {
@@ -42,8 +60,8 @@ func (clt *Client) GetToken(autoRefresh bool) (tk *Token, err error) {
 			return
 		}
 	}
-	if autoRefresh && tk.Expired() {
-		return clt.RefreshToken(tk.RefreshToken)
+	if autoRefresh && tk.ExpiresWithin(clt.refreshAhead()) {
+		return clt.refreshToken(tk)
 	}
 	return
 }
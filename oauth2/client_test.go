@@ -0,0 +1,135 @@
+package oauth2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testEndpoint struct {
+	url string
+}
+
+func (p testEndpoint) ExchangeTokenURL(code string) string {
+	return p.url + "/access_token?code=" + code
+}
+
+func (p testEndpoint) RefreshTokenURL(refreshToken string) string {
+	return p.url + "/refresh_token?refresh_token=" + refreshToken
+}
+
+func TestClientGetTokenRefreshOnce(t *testing.T) {
+	var refreshCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&refreshCount, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(`{"access_token":"NEW_ACCESS_TOKEN","expires_in":7200,"refresh_token":"REFRESH_TOKEN","openid":"OPENID","scope":"snsapi_base"}`))
+	}))
+	defer server.Close()
+
+	createdAt := time.Now().Unix() - 7200
+	store := NewMemoryStore(time.Hour)
+	store.Put("OPENID", &Token{
+		AccessToken:           "OLD_ACCESS_TOKEN",
+		CreatedAt:             createdAt,
+		ExpiresIn:             6000,
+		RefreshToken:          "REFRESH_TOKEN",
+		RefreshTokenCreatedAt: createdAt,
+		OpenId:                "OPENID",
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clt := &Client{
+				Endpoint:     testEndpoint{url: server.URL},
+				TokenStorage: store.TokenStorage("OPENID"),
+			}
+			token, err := clt.GetToken(true)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if token.AccessToken != "NEW_ACCESS_TOKEN" {
+				t.Errorf("have %q, want %q", token.AccessToken, "NEW_ACCESS_TOKEN")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&refreshCount); n != 1 {
+		t.Errorf("have %d refresh requests, want 1", n)
+	}
+	token, _ := store.Get("OPENID")
+	if token.RefreshTokenCreatedAt != createdAt {
+		t.Errorf("refresh_token_created_at changed by refresh: %d", token.RefreshTokenCreatedAt)
+	}
+}
+
+// 不同 Endpoint 的 openid 相同时, 并发的刷新请求不能合并.
+func TestClientGetTokenRefreshPerEndpoint(t *testing.T) {
+	newServer := func(accessToken string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+			w.Write([]byte(`{"access_token":"` + accessToken + `","expires_in":7200,"refresh_token":"REFRESH_TOKEN","openid":"OPENID","scope":"snsapi_base"}`))
+		}))
+	}
+	server1, server2 := newServer("ACCESS_TOKEN_1"), newServer("ACCESS_TOKEN_2")
+	defer server1.Close()
+	defer server2.Close()
+
+	createdAt := time.Now().Unix() - 7200
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		server, want := server1, "ACCESS_TOKEN_1"
+		if i%2 == 1 {
+			server, want = server2, "ACCESS_TOKEN_2"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clt := &Client{
+				Endpoint: testEndpoint{url: server.URL},
+				Token: &Token{
+					AccessToken:           "OLD_ACCESS_TOKEN",
+					CreatedAt:             createdAt,
+					ExpiresIn:             6000,
+					RefreshToken:          "REFRESH_TOKEN",
+					RefreshTokenCreatedAt: createdAt,
+					OpenId:                "OPENID",
+				},
+			}
+			token, err := clt.GetToken(true)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if token.AccessToken != want {
+				t.Errorf("have %q, want %q", token.AccessToken, want)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestClientGetTokenReauthorizationRequired(t *testing.T) {
+	clt := &Client{
+		Endpoint: testEndpoint{url: "http://127.0.0.1:0"},
+		Token: &Token{
+			AccessToken:           "ACCESS_TOKEN",
+			CreatedAt:             time.Now().Unix() - 7200,
+			ExpiresIn:             6000,
+			RefreshToken:          "REFRESH_TOKEN",
+			RefreshTokenCreatedAt: time.Now().Unix() - RefreshTokenExpiresIn,
+			OpenId:                "OPENID",
+		},
+	}
+	if _, err := clt.GetToken(true); err != ErrReauthorizationRequired {
+		t.Errorf("have %v, want %v", err, ErrReauthorizationRequired)
+	}
+}
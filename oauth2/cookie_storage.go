@@ -0,0 +1,165 @@
+package oauth2
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// CookieStore 把 Token 用 AES-GCM 加密后存放在客户端的 cookie 里, 服务端无需任何存储, 适用于分布式环境.
+//
+//	NOTE:
+//	1. Key 的长度必须是 16, 24 或者 32 字节, 分别对应 AES-128, AES-192 和 AES-256;
+//	2. 同一个系统的所有服务器必须使用相同的 Key;
+//	3. cookie 的大小有限制(一般为 4KB), Token 加密后一般在 1KB 以内.
+type CookieStore struct {
+	Name     string        // cookie 名称, 如果为空则默认为 "wechat_oauth2_token"
+	Key      []byte        // AES key
+	Path     string        // 如果为空则默认为 "/"
+	Domain   string        // cookie 的 Domain 属性
+	MaxAge   time.Duration // cookie 的存活时间, 如果 <= 0 则默认为 30 天(refresh_token 的有效期)
+	Secure   bool          // cookie 的 Secure 属性
+	HttpOnly bool          // cookie 的 HttpOnly 属性, 一般都应该设置为 true
+}
+
+const defaultCookieStoreName = "wechat_oauth2_token"
+
+func (store *CookieStore) name() string {
+	if store.Name != "" {
+		return store.Name
+	}
+	return defaultCookieStoreName
+}
+
+func (store *CookieStore) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(store.Key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// TokenStorage 返回当前请求对应的 TokenStorage, 一般用于 Client.TokenStorage.
+//
+//	Token 从 r 的 cookie 里读取, PutToken 通过 w 设置 cookie, 所以 PutToken 必须在写 http body 之前调用.
+func (store *CookieStore) TokenStorage(w http.ResponseWriter, r *http.Request) TokenStorage {
+	return &cookieTokenStorage{store: store, w: w, r: r}
+}
+
+// Seal 加密 token, 返回的字符串可以直接作为 cookie 的值.
+func (store *CookieStore) Seal(token *Token) (string, error) {
+	if token == nil {
+		return "", errors.New("nil token")
+	}
+	aead, err := store.aead()
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	// nonce + ciphertext, 用 cookie 名称作为附加数据, 防止不同 cookie 的值被互相替换
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(store.name()))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open 解密 Seal 返回的字符串.
+func (store *CookieStore) Open(value string) (*Token, error) {
+	aead, err := store.aead()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("invalid sealed token")
+	}
+	plaintext, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(store.name()))
+	if err != nil {
+		return nil, err
+	}
+
+	var token Token
+	if err = json.Unmarshal(plaintext, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Delete 删除客户端的 cookie.
+func (store *CookieStore) Delete(w http.ResponseWriter) {
+	http.SetCookie(w, store.newCookie("", -1))
+}
+
+func (store *CookieStore) newCookie(value string, maxAge int) *http.Cookie {
+	path := store.Path
+	if path == "" {
+		path = "/"
+	}
+	cookie := &http.Cookie{
+		Name:     store.name(),
+		Value:    value,
+		Path:     path,
+		Domain:   store.Domain,
+		MaxAge:   maxAge,
+		Secure:   store.Secure,
+		HttpOnly: store.HttpOnly,
+	}
+	if maxAge > 0 {
+		cookie.Expires = time.Now().Add(time.Duration(maxAge) * time.Second)
+	}
+	return cookie
+}
+
+var _ TokenStorage = (*cookieTokenStorage)(nil)
+
+type cookieTokenStorage struct {
+	store *CookieStore
+	w     http.ResponseWriter
+	r     *http.Request
+
+	token *Token // PutToken 之后同一个请求内 Token 返回最新的值
+}
+
+func (storage *cookieTokenStorage) Token() (*Token, error) {
+	if storage.token != nil {
+		token := *storage.token
+		return &token, nil
+	}
+	cookie, err := storage.r.Cookie(storage.store.name())
+	if err != nil {
+		return nil, ErrTokenNotFound
+	}
+	return storage.store.Open(cookie.Value)
+}
+
+func (storage *cookieTokenStorage) PutToken(token *Token) error {
+	value, err := storage.store.Seal(token)
+	if err != nil {
+		return err
+	}
+	maxAge := storage.store.MaxAge
+	if maxAge <= 0 {
+		maxAge = 30 * 24 * time.Hour
+	}
+	http.SetCookie(storage.w, storage.store.newCookie(value, int(maxAge/time.Second)))
+
+	tokenCopy := *token
+	storage.token = &tokenCopy
+	return nil
+}
@@ -1,13 +1,20 @@
 package oauth2
 
 import (
+	"errors"
 	"fmt"
 )
 
 const (
 	ErrCodeOK = 0
+
+	ErrCodeInvalidRefreshToken = 40030 // 不合法的 refresh_token
+	ErrCodeRefreshTokenExpired = 42002 // refresh_token 超时
 )
 
+// ErrReauthorizationRequired 表示 refresh_token 已经过期或者失效, 无法再刷新 access_token, 需要用户重新授权.
+var ErrReauthorizationRequired = errors.New("oauth2 reauthorization required")
+
 type Error struct {
 	ErrCode int64  `json:"errcode"`
 	ErrMsg  string `json:"errmsg"`
@@ -16,3 +23,14 @@ type Error struct {
 func (err *Error) Error() string {
 	return fmt.Sprintf("errcode: %d, errmsg: %s", err.ErrCode, err.ErrMsg)
 }
+
+// refreshError 把 refresh_token 失效的错误转换为 ErrReauthorizationRequired, 其他错误原样返回.
+func refreshError(err error) error {
+	if e, ok := err.(*Error); ok {
+		switch e.ErrCode {
+		case ErrCodeInvalidRefreshToken, ErrCodeRefreshTokenExpired:
+			return ErrReauthorizationRequired
+		}
+	}
+	return err
+}
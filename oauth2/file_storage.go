@@ -0,0 +1,121 @@
+package oauth2
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore 把 Token 以 JSON 格式存放在 dir 目录下, 每个 key(一般为 openid 或者 session id) 对应一个文件.
+//
+//	NOTE: 同一个目录只能被一个 FileStore 使用, 多进程共享同一个目录时不保证数据一致.
+type FileStore struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewFileStore 创建一个新的 FileStore, 如果 dir 不存在则自动创建.
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, errors.New("empty dir")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// TokenStorage 返回 key 对应的 TokenStorage, 一般用于 Client.TokenStorage.
+func (store *FileStore) TokenStorage(key string) TokenStorage {
+	return &fileTokenStorage{store: store, key: key}
+}
+
+// filename 返回 key 对应的文件路径, key 可能含有文件系统不允许的字符, 所以用 sha1(key) 作为文件名.
+func (store *FileStore) filename(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(store.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get 获取 key 对应的 Token, 没有找到返回 ErrTokenNotFound.
+func (store *FileStore) Get(key string) (*Token, error) {
+	store.mu.RLock()
+	data, err := ioutil.ReadFile(store.filename(key))
+	store.mu.RUnlock()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, err
+	}
+
+	var token Token
+	if err = json.Unmarshal(data, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Put 存放 key 对应的 Token, 先写入临时文件然后 rename, 避免写入过程中被读到不完整的数据.
+func (store *FileStore) Put(key string, token *Token) (err error) {
+	if token == nil {
+		return errors.New("nil token")
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	file, err := ioutil.TempFile(store.dir, ".token-")
+	if err != nil {
+		return
+	}
+	tempName := file.Name()
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		os.Remove(tempName)
+		return
+	}
+	if err = file.Close(); err != nil {
+		os.Remove(tempName)
+		return
+	}
+	if err = os.Rename(tempName, store.filename(key)); err != nil {
+		os.Remove(tempName)
+		return
+	}
+	return
+}
+
+// Delete 删除 key 对应的 Token.
+func (store *FileStore) Delete(key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if err := os.Remove(store.filename(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+var _ TokenStorage = (*fileTokenStorage)(nil)
+
+type fileTokenStorage struct {
+	store *FileStore
+	key   string
+}
+
+func (storage *fileTokenStorage) Token() (*Token, error) {
+	return storage.store.Get(storage.key)
+}
+
+func (storage *fileTokenStorage) PutToken(token *Token) error {
+	return storage.store.Put(storage.key, token)
+}
@@ -0,0 +1,116 @@
+package oauth2
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTokenNotFound 表示 TokenStorage 里没有找到对应的 Token(或者已经超时被清除).
+var ErrTokenNotFound = errors.New("token not found")
+
+// MemoryStore 是一个进程内的 Token 存储, 按 key(一般为 openid 或者 session id) 存放 Token, 每个 Token 有一个存活时间(TTL).
+//
+//	NOTE: 用于单进程环境, 多进程(分布式)环境请使用 CookieStore 或者自己实现 TokenStorage.
+type MemoryStore struct {
+	ttl time.Duration
+
+	mu     sync.Mutex
+	tokens map[string]memoryStoreItem
+}
+
+type memoryStoreItem struct {
+	token    Token
+	expireAt time.Time
+}
+
+// NewMemoryStore 创建一个新的 MemoryStore, ttl 为 Token 在 MemoryStore 中的存活时间, 每次 PutToken 都会重新计时.
+//
+//	网页授权的 refresh_token 有效期为 30 天, 所以 ttl 一般不需要超过 30 天.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	if ttl <= 0 {
+		panic("ttl must be positive")
+	}
+	store := &MemoryStore{
+		ttl:    ttl,
+		tokens: make(map[string]memoryStoreItem),
+	}
+	return store
+}
+
+// TokenStorage 返回 key 对应的 TokenStorage, 一般用于 Client.TokenStorage.
+func (store *MemoryStore) TokenStorage(key string) TokenStorage {
+	return &memoryTokenStorage{store: store, key: key}
+}
+
+// Get 获取 key 对应的 Token, 没有找到或者已经超时返回 ErrTokenNotFound.
+func (store *MemoryStore) Get(key string) (*Token, error) {
+	now := time.Now()
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	item, ok := store.tokens[key]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	if !now.Before(item.expireAt) {
+		delete(store.tokens, key)
+		return nil, ErrTokenNotFound
+	}
+	token := item.token
+	return &token, nil
+}
+
+// Put 存放 key 对应的 Token.
+func (store *MemoryStore) Put(key string, token *Token) error {
+	if token == nil {
+		return errors.New("nil token")
+	}
+	now := time.Now()
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.tokens[key] = memoryStoreItem{
+		token:    *token,
+		expireAt: now.Add(store.ttl),
+	}
+	return nil
+}
+
+// Delete 删除 key 对应的 Token.
+func (store *MemoryStore) Delete(key string) {
+	store.mu.Lock()
+	delete(store.tokens, key)
+	store.mu.Unlock()
+}
+
+// GC 清除所有已经超时的 Token, 调用者可以定时调用以释放内存.
+func (store *MemoryStore) GC() {
+	now := time.Now()
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for key, item := range store.tokens {
+		if !now.Before(item.expireAt) {
+			delete(store.tokens, key)
+		}
+	}
+}
+
+var _ TokenStorage = (*memoryTokenStorage)(nil)
+
+type memoryTokenStorage struct {
+	store *MemoryStore
+	key   string
+}
+
+func (storage *memoryTokenStorage) Token() (*Token, error) {
+	return storage.store.Get(storage.key)
+}
+
+func (storage *memoryTokenStorage) PutToken(token *Token) error {
+	return storage.store.Put(storage.key, token)
+}
@@ -14,12 +14,18 @@ type TokenStorage interface {
 	PutToken(*Token) error
 }
 
+// RefreshTokenExpiresIn 是 refresh_token 的有效期, 单位: 秒; 微信规定为 30 天, 并且刷新 access_token 不会延长 refresh_token 的有效期.
+const RefreshTokenExpiresIn = 60 * 60 * 24 * 30
+
 type Token struct {
 	AccessToken  string `json:"access_token"`            // 网页授权接口调用凭证
 	CreatedAt    int64  `json:"created_at"`              // access_token 创建时间, unixtime, 分布式系统要求时间同步, 建议使用 NTP
 	ExpiresIn    int64  `json:"expires_in"`              // access_token 接口调用凭证超时时间, 单位: 秒
 	RefreshToken string `json:"refresh_token,omitempty"` // 刷新 access_token 的凭证
 
+	// refresh_token 创建时间, unixtime; 为 0 表示未知(比如旧版本保存的 Token), 此时认为 refresh_token 没有过期.
+	RefreshTokenCreatedAt int64 `json:"refresh_token_created_at,omitempty"`
+
 	OpenId  string `json:"openid,omitempty"`
 	UnionId string `json:"unionid,omitempty"`
 	Scope   string `json:"scope,omitempty"` // 用户授权的作用域, 使用逗号(,)分隔
@@ -29,3 +35,21 @@ type Token struct {
 func (token *Token) Expired() bool {
 	return time.Now().Unix() >= token.CreatedAt+token.ExpiresIn
 }
+
+// ExpiresWithin 判断 token.AccessToken 是否会在 d 时间内过期(包括已经过期), 是返回 true, 否则返回 false.
+func (token *Token) ExpiresWithin(d time.Duration) bool {
+	return time.Now().Add(d).Unix() >= token.CreatedAt+token.ExpiresIn
+}
+
+// RefreshTokenExpired 判断 token.RefreshToken 是否过期, 过期返回 true, 否则返回 false.
+//
+//	NOTE: 为了应对网络延时和服务器之间的时间误差, 过期时间留有 1 小时的缓冲区.
+func (token *Token) RefreshTokenExpired() bool {
+	if token.RefreshToken == "" {
+		return true
+	}
+	if token.RefreshTokenCreatedAt == 0 {
+		return false
+	}
+	return time.Now().Unix() >= token.RefreshTokenCreatedAt+RefreshTokenExpiresIn-60*60
+}
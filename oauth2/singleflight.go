@@ -0,0 +1,59 @@
+package oauth2
+
+import (
+	"errors"
+	"sync"
+)
+
+// refreshGroup 保证同一个 key 同一时刻只有一个刷新 access_token 的请求, 其他并发的调用者等待并共享这个请求的结果.
+var refreshGroup singleflightGroup
+
+type singleflightCall struct {
+	wg    sync.WaitGroup
+	token Token
+	err   error
+}
+
+type singleflightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*singleflightCall
+}
+
+// Do 执行 fn 并返回结果, 如果同一个 key 已经有正在执行的 fn, 则等待其完成并返回它的结果.
+func (g *singleflightGroup) Do(key string, fn func() (*Token, error)) (*Token, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*singleflightCall)
+	}
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.result()
+	}
+	call := &singleflightCall{err: errors.New("refresh token aborted")} // fn panic 时等待者得到这个错误
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		call.wg.Done()
+	}()
+
+	token, err := fn()
+	if call.err = err; err == nil {
+		call.token = *token
+	}
+	return call.result()
+}
+
+// result 返回结果的拷贝, 调用者之间不共享同一个 *Token.
+func (call *singleflightCall) result() (*Token, error) {
+	if call.err != nil {
+		return nil, call.err
+	}
+	token := call.token
+	return &token, nil
+}
@@ -0,0 +1,111 @@
+package oauth2
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+var testToken = Token{
+	AccessToken:  "ACCESS_TOKEN",
+	CreatedAt:    1457678653,
+	ExpiresIn:    6000,
+	RefreshToken: "REFRESH_TOKEN",
+	OpenId:       "OPENID",
+	Scope:        "snsapi_base",
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	storage := store.TokenStorage("key")
+	if _, err := storage.Token(); err != ErrTokenNotFound {
+		t.Fatalf("have %v, want %v", err, ErrTokenNotFound)
+	}
+	if err := storage.PutToken(&testToken); err != nil {
+		t.Fatal(err)
+	}
+	token, err := storage.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *token != testToken {
+		t.Errorf("have %+v, want %+v", *token, testToken)
+	}
+
+	store = NewMemoryStore(time.Nanosecond)
+	store.Put("key", &testToken)
+	time.Sleep(time.Millisecond)
+	if _, err := store.Get("key"); err != ErrTokenNotFound {
+		t.Errorf("have %v, want %v", err, ErrTokenNotFound)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oauth2-filestore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := store.TokenStorage("key/with/slash")
+	if _, err := storage.Token(); err != ErrTokenNotFound {
+		t.Fatalf("have %v, want %v", err, ErrTokenNotFound)
+	}
+	if err := storage.PutToken(&testToken); err != nil {
+		t.Fatal(err)
+	}
+	token, err := storage.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *token != testToken {
+		t.Errorf("have %+v, want %+v", *token, testToken)
+	}
+	if err = store.Delete("key/with/slash"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.Token(); err != ErrTokenNotFound {
+		t.Errorf("have %v, want %v", err, ErrTokenNotFound)
+	}
+}
+
+func TestCookieStore(t *testing.T) {
+	store := &CookieStore{
+		Key:      []byte("0123456789abcdef0123456789abcdef"),
+		HttpOnly: true,
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if err := store.TokenStorage(w, r).PutToken(&testToken); err != nil {
+		t.Fatal(err)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("have %d cookies, want 1", len(cookies))
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(cookies[0])
+	token, err := store.TokenStorage(httptest.NewRecorder(), r).Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *token != testToken {
+		t.Errorf("have %+v, want %+v", *token, testToken)
+	}
+
+	// 篡改过的 cookie 必须解密失败
+	value := []byte(cookies[0].Value)
+	value[len(value)/2] ^= 1
+	if _, err = store.Open(string(value)); err == nil {
+		t.Error("tampered cookie opened successfully")
+	}
+}
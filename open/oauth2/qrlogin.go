@@ -0,0 +1,249 @@
+package oauth2
+
+import (
+	"crypto/subtle"
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+
+	mpoauth2 "github.com/chanxuehong/wechat/mp/oauth2"
+	"github.com/chanxuehong/wechat/oauth2"
+	"github.com/chanxuehong/wechat/util"
+)
+
+// ScopeLogin 是网站应用微信登录的授权作用域.
+const ScopeLogin = "snsapi_login"
+
+var (
+	ErrStateMismatch = mpoauth2.ErrStateMismatch
+	ErrAuthDenied    = mpoauth2.ErrAuthDenied
+)
+
+// WxLoginConfig 是网页内嵌二维码登录 JS(wxLogin.js) 的参数, 可以直接 JSON 序列化后传给 new WxLogin(...).
+type WxLoginConfig struct {
+	SelfRedirect bool   `json:"self_redirect"`   // true: 手机点击确认登录后可以在 iframe 内跳转到 redirect_uri, false: 手机点击确认登录后可以在 top window 跳转到 redirect_uri
+	Id           string `json:"id"`              // 第三方页面显示二维码的容器 id
+	AppId        string `json:"appid"`           // 应用唯一标识
+	Scope        string `json:"scope"`           // 应用授权作用域, 网页应用目前仅填写 snsapi_login 即可
+	RedirectURI  string `json:"redirect_uri"`    // 重定向地址, 已经 urlEncode
+	State        string `json:"state"`           // 用于保持请求和回调的状态
+	Style        string `json:"style,omitempty"` // 提供 "black", "white" 可选, 默认为黑色文字描述
+	Href         string `json:"href,omitempty"`  // 自定义样式链接, 第三方可根据实际需求覆盖默认样式
+}
+
+// LoginInfo 是 QRLogin 回调处理完成后的登录信息.
+type LoginInfo struct {
+	OpenId   string // 用户在网站应用下的 openid
+	UnionId  string
+	Token    *oauth2.Token
+	UserInfo *UserInfo
+
+	// 同一个 unionid 在各个应用下的 openid(包括本网站应用), key 为 appid;
+	// 只有指定了 QRLogin.UnionIdStore 并且 UnionId 不为空才有值.
+	OpenIds map[string]string
+}
+
+var defaultWxLoginTemplate = template.Must(template.New("wxlogin").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>微信登录</title>
+<script src="https://res.wx.qq.com/connect/zh_CN/htmledition/js/wxLogin.js"></script>
+</head>
+<body>
+<div id="{{.Id}}"></div>
+<script>new WxLogin({{.}});</script>
+</body>
+</html>
+`))
+
+// QRLogin 实现了网站应用微信扫码登录的完整流程.
+//
+//	LoginHandler:    跳转到 qrconnect 页面, 或者渲染内嵌二维码的页面;
+//	CallbackHandler: 校验 state, 用 code 换取 Token 并获取用户信息, 合并 unionid, 最后调用 LinkAccount.
+type QRLogin struct {
+	Endpoint    *Endpoint // 必须指定
+	RedirectURI string    // 必须指定, 授权后重定向的回调链接地址, 即 CallbackHandler 的完整 URL
+	Lang        string    // 获取用户信息的语言版本, 参考 GetUserInfo
+
+	// Embed 为 true 时 LoginHandler 渲染内嵌二维码的页面, 否则跳转到微信的 qrconnect 页面.
+	Embed bool
+	// Template 为内嵌二维码页面的模板, 模板的数据为 *WxLoginConfig, 如果为 nil 则使用默认的模板.
+	Template     *template.Template
+	ContainerId  string // 内嵌二维码的容器 id, 如果为空则默认为 "login_container"
+	Style        string // 参考 WxLoginConfig.Style
+	Href         string // 参考 WxLoginConfig.Href
+	SelfRedirect bool   // 参考 WxLoginConfig.SelfRedirect
+
+	StateCookieName string // 保存 state 的 cookie 名称, 如果为空则默认为 "wechat_qrlogin_state"
+	SecureCookie    bool   // state cookie 的 Secure 属性
+
+	// UnionIdStore 不为 nil 时, 记录 unionid 和 openid 的对应关系, 并在 LoginInfo.OpenIds 返回同一个 unionid 在其他应用(比如公众号)下的 openid.
+	UnionIdStore UnionIdStore
+
+	// LinkAccount 必须指定, 根据 info 创建或者关联本地用户账号, 然后完成登录(比如设置 session 并跳转到首页).
+	// 一般优先用 info.UnionId 查找本地账号, 这样同一个用户在公众号和网站应用的登录会对应到同一个账号.
+	LinkAccount func(w http.ResponseWriter, r *http.Request, info *LoginInfo)
+
+	// ErrorHandler 处理登录过程中的错误, 如果为 nil 则 ErrStateMismatch 和 ErrAuthDenied 返回 403, 其他错误返回 500.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+	HttpClient *http.Client // 如果 HttpClient == nil 则默认用 util.DefaultHttpClient
+}
+
+const defaultQRLoginStateCookieName = "wechat_qrlogin_state"
+
+func (q *QRLogin) check() {
+	if q.Endpoint == nil {
+		panic("nil QRLogin.Endpoint")
+	}
+	if q.RedirectURI == "" {
+		panic("empty QRLogin.RedirectURI")
+	}
+	if q.LinkAccount == nil {
+		panic("nil QRLogin.LinkAccount")
+	}
+}
+
+// LoginHandler 返回发起扫码登录的 http.Handler.
+func (q *QRLogin) LoginHandler() http.Handler {
+	q.check()
+	return http.HandlerFunc(q.serveLogin)
+}
+
+// CallbackHandler 返回处理授权回调的 http.Handler.
+func (q *QRLogin) CallbackHandler() http.Handler {
+	q.check()
+	return http.HandlerFunc(q.serveCallback)
+}
+
+// WxLoginConfig 生成内嵌二维码登录 JS 的参数.
+func (q *QRLogin) WxLoginConfig(state string) *WxLoginConfig {
+	id := q.ContainerId
+	if id == "" {
+		id = "login_container"
+	}
+	return &WxLoginConfig{
+		SelfRedirect: q.SelfRedirect,
+		Id:           id,
+		AppId:        q.Endpoint.AppId,
+		Scope:        ScopeLogin,
+		RedirectURI:  url.QueryEscape(q.RedirectURI),
+		State:        state,
+		Style:        q.Style,
+		Href:         q.Href,
+	}
+}
+
+func (q *QRLogin) serveLogin(w http.ResponseWriter, r *http.Request) {
+	state := util.NonceStr()
+	http.SetCookie(w, q.newStateCookie(state, 10*60))
+
+	if !q.Embed {
+		http.Redirect(w, r, AuthCodeURL(q.Endpoint.AppId, q.RedirectURI, ScopeLogin, state), http.StatusFound)
+		return
+	}
+
+	tpl := q.Template
+	if tpl == nil {
+		tpl = defaultWxLoginTemplate
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tpl.Execute(w, q.WxLoginConfig(state)); err != nil {
+		q.handleError(w, r, err)
+		return
+	}
+}
+
+func (q *QRLogin) serveCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	cookie, err := r.Cookie(q.stateCookieName())
+	if err != nil {
+		q.handleError(w, r, ErrStateMismatch)
+		return
+	}
+	http.SetCookie(w, q.newStateCookie("", -1)) // state 只能使用一次
+	if subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(query.Get("state"))) != 1 {
+		q.handleError(w, r, ErrStateMismatch)
+		return
+	}
+	code := query.Get("code")
+	if code == "" {
+		q.handleError(w, r, ErrAuthDenied)
+		return
+	}
+
+	client := &oauth2.Client{
+		Endpoint:   q.Endpoint,
+		HttpClient: q.HttpClient,
+	}
+	token, err := client.ExchangeToken(code)
+	if err != nil {
+		q.handleError(w, r, err)
+		return
+	}
+	userinfo, err := GetUserInfo(token.AccessToken, token.OpenId, q.Lang, q.HttpClient)
+	if err != nil {
+		q.handleError(w, r, err)
+		return
+	}
+
+	info := &LoginInfo{
+		OpenId:   token.OpenId,
+		UnionId:  token.UnionId,
+		Token:    token,
+		UserInfo: userinfo,
+	}
+	if info.UnionId == "" {
+		info.UnionId = userinfo.UnionId
+	}
+	if q.UnionIdStore != nil && info.UnionId != "" {
+		if err = q.UnionIdStore.Bind(info.UnionId, q.Endpoint.AppId, info.OpenId); err != nil {
+			q.handleError(w, r, err)
+			return
+		}
+		if info.OpenIds, err = q.UnionIdStore.OpenIds(info.UnionId); err != nil {
+			q.handleError(w, r, err)
+			return
+		}
+	}
+	q.LinkAccount(w, r, info)
+}
+
+func (q *QRLogin) stateCookieName() string {
+	if q.StateCookieName != "" {
+		return q.StateCookieName
+	}
+	return defaultQRLoginStateCookieName
+}
+
+func (q *QRLogin) newStateCookie(value string, maxAge int) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     q.stateCookieName(),
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   q.SecureCookie,
+		HttpOnly: true,
+	}
+	if maxAge > 0 {
+		cookie.Expires = time.Now().Add(time.Duration(maxAge) * time.Second)
+	}
+	return cookie
+}
+
+func (q *QRLogin) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if q.ErrorHandler != nil {
+		q.ErrorHandler(w, r, err)
+		return
+	}
+	switch err {
+	case ErrStateMismatch, ErrAuthDenied:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
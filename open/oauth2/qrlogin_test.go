@@ -0,0 +1,164 @@
+package oauth2
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chanxuehong/wechat/internal/testutil"
+)
+
+// newWeixinServer 模拟 sns/oauth2/access_token 和 sns/userinfo 接口.
+func newWeixinServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sns/oauth2/access_token":
+			if code := r.URL.Query().Get("code"); code != "CODE" {
+				io.WriteString(w, `{"errcode":40029,"errmsg":"invalid code"}`)
+				return
+			}
+			io.WriteString(w, `{"access_token":"ACCESS_TOKEN","expires_in":7200,"refresh_token":"REFRESH_TOKEN","openid":"WEB_OPENID","scope":"snsapi_login"}`)
+		case "/sns/userinfo":
+			io.WriteString(w, `{"openid":"WEB_OPENID","nickname":"NICKNAME","unionid":"UNIONID"}`)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+}
+
+func newTestQRLogin(srv *httptest.Server, infos *[]*LoginInfo) *QRLogin {
+	return &QRLogin{
+		Endpoint:     NewEndpoint("WEB_APPID", "APPSECRET"),
+		RedirectURI:  "https://example.com/callback",
+		UnionIdStore: NewMemoryUnionIdStore(),
+		LinkAccount: func(w http.ResponseWriter, r *http.Request, info *LoginInfo) {
+			*infos = append(*infos, info)
+			io.WriteString(w, "ok")
+		},
+		HttpClient: &http.Client{Transport: testutil.RewriteTransport{Target: srv.URL}},
+	}
+}
+
+// login 请求 LoginHandler, 返回 state cookie.
+func login(t *testing.T, q *QRLogin) *http.Cookie {
+	w := httptest.NewRecorder()
+	q.LoginHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != defaultQRLoginStateCookieName || cookies[0].Value == "" || !cookies[0].HttpOnly {
+		t.Fatalf("unexpected cookies: %v", cookies)
+	}
+	return cookies[0]
+}
+
+func callback(q *QRLogin, query string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/callback?"+query, nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	q.CallbackHandler().ServeHTTP(w, r)
+	return w
+}
+
+func TestQRLoginRedirect(t *testing.T) {
+	srv := newWeixinServer(t)
+	defer srv.Close()
+	var infos []*LoginInfo
+	q := newTestQRLogin(srv, &infos)
+
+	w := httptest.NewRecorder()
+	q.LoginHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("status = %d", w.Code)
+	}
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := location.Query()
+	cookie := w.Result().Cookies()[0]
+	if location.Host != "open.weixin.qq.com" || query.Get("appid") != "WEB_APPID" || query.Get("scope") != ScopeLogin ||
+		query.Get("redirect_uri") != q.RedirectURI || query.Get("state") != cookie.Value {
+		t.Errorf("unexpected Location %s, state cookie %s", location, cookie.Value)
+	}
+}
+
+func TestQRLoginEmbed(t *testing.T) {
+	srv := newWeixinServer(t)
+	defer srv.Close()
+	var infos []*LoginInfo
+	q := newTestQRLogin(srv, &infos)
+	q.Embed = true
+
+	w := httptest.NewRecorder()
+	q.LoginHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+	body := w.Body.String()
+	cookie := w.Result().Cookies()[0]
+	for _, want := range []string{`id="login_container"`, `wxLogin.js`, `"appid":"WEB_APPID"`, `"state":"` + cookie.Value + `"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body does not contain %s:\n%s", want, body)
+		}
+	}
+}
+
+func TestQRLoginCallback(t *testing.T) {
+	srv := newWeixinServer(t)
+	defer srv.Close()
+	var infos []*LoginInfo
+	q := newTestQRLogin(srv, &infos)
+	q.UnionIdStore.Bind("UNIONID", "MP_APPID", "MP_OPENID")
+
+	cookie := login(t, q)
+	w := callback(q, "code=CODE&state="+cookie.Value, cookie)
+	if w.Code != http.StatusOK || len(infos) != 1 {
+		t.Fatalf("status = %d, body = %q, infos = %v", w.Code, w.Body.String(), infos)
+	}
+	info := infos[0]
+	if info.OpenId != "WEB_OPENID" || info.UnionId != "UNIONID" || info.Token.AccessToken != "ACCESS_TOKEN" || info.UserInfo.Nickname != "NICKNAME" {
+		t.Errorf("info = %+v", info)
+	}
+	if want := map[string]string{"MP_APPID": "MP_OPENID", "WEB_APPID": "WEB_OPENID"}; !reflect.DeepEqual(info.OpenIds, want) {
+		t.Errorf("OpenIds = %v, want %v", info.OpenIds, want)
+	}
+	// 回调之后清除 state cookie
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Errorf("state cookie not cleared: %v", cookies)
+	}
+}
+
+func TestQRLoginCallbackErrors(t *testing.T) {
+	srv := newWeixinServer(t)
+	defer srv.Close()
+	var infos []*LoginInfo
+	q := newTestQRLogin(srv, &infos)
+	var errs []error
+	q.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		errs = append(errs, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+
+	cookie := login(t, q)
+	callback(q, "code=CODE&state="+cookie.Value, nil)       // 没有 state cookie
+	callback(q, "code=CODE&state=OTHER", cookie)            // state 不一致
+	callback(q, "state="+cookie.Value, cookie)              // 用户拒绝授权
+	callback(q, "code=BADCODE&state="+cookie.Value, cookie) // code 无效
+	if len(errs) != 4 || errs[0] != ErrStateMismatch || errs[1] != ErrStateMismatch || errs[2] != ErrAuthDenied || errs[3] == nil {
+		t.Errorf("errs = %v", errs)
+	}
+	if len(infos) != 0 {
+		t.Errorf("LinkAccount called: %v", infos)
+	}
+
+	// 默认的 ErrorHandler
+	q.ErrorHandler = nil
+	if w := callback(q, "code=CODE&state=OTHER", cookie); w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if w := callback(q, "code=BADCODE&state="+cookie.Value, cookie); w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
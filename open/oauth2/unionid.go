@@ -0,0 +1,54 @@
+package oauth2
+
+import (
+	"sync"
+)
+
+// UnionIdStore 记录 unionid 和同一开放平台帐号下各个应用(公众号, 网站应用, 移动应用...)的 openid 的对应关系,
+// 用于把同一个用户在不同应用下的登录合并到同一个本地账号.
+//
+//	QRLogin 会自动 Bind 网站应用的 openid; 公众号网页授权(mp/oauth2.Middleware)拿到 unionid 后, 调用者自己 Bind 公众号的 openid.
+type UnionIdStore interface {
+	// Bind 记录 unionId 在 appId 应用下的 openId.
+	Bind(unionId, appId, openId string) error
+	// OpenIds 返回 unionId 在各个应用下的 openid, key 为 appid, 没有记录返回空的 map.
+	OpenIds(unionId string) (map[string]string, error)
+}
+
+var _ UnionIdStore = (*MemoryUnionIdStore)(nil)
+
+// MemoryUnionIdStore 是进程内的 UnionIdStore 实现, 一般用于测试或者单进程环境.
+type MemoryUnionIdStore struct {
+	mu sync.RWMutex
+	m  map[string]map[string]string // unionid -> appid -> openid
+}
+
+func NewMemoryUnionIdStore() *MemoryUnionIdStore {
+	return &MemoryUnionIdStore{
+		m: make(map[string]map[string]string),
+	}
+}
+
+func (store *MemoryUnionIdStore) Bind(unionId, appId, openId string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	openIds := store.m[unionId]
+	if openIds == nil {
+		openIds = make(map[string]string)
+		store.m[unionId] = openIds
+	}
+	openIds[appId] = openId
+	return nil
+}
+
+func (store *MemoryUnionIdStore) OpenIds(unionId string) (map[string]string, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	openIds := make(map[string]string, len(store.m[unionId]))
+	for appId, openId := range store.m[unionId] {
+		openIds[appId] = openId
+	}
+	return openIds, nil
+}
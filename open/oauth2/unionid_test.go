@@ -0,0 +1,47 @@
+package oauth2
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestMemoryUnionIdStore(t *testing.T) {
+	store := NewMemoryUnionIdStore()
+
+	openIds, err := store.OpenIds("UNIONID")
+	if err != nil || openIds == nil || len(openIds) != 0 {
+		t.Errorf("OpenIds of unknown unionid = (%v, %v), want empty map", openIds, err)
+	}
+
+	store.Bind("UNIONID", "APPID1", "OPENID1")
+	store.Bind("UNIONID", "APPID2", "OPENID2")
+	store.Bind("UNIONID", "APPID1", "OPENID3") // 覆盖
+	store.Bind("OTHER", "APPID1", "OPENID4")
+
+	openIds, _ = store.OpenIds("UNIONID")
+	if want := map[string]string{"APPID1": "OPENID3", "APPID2": "OPENID2"}; !reflect.DeepEqual(openIds, want) {
+		t.Errorf("have %v, want %v", openIds, want)
+	}
+
+	// 返回的是副本
+	openIds["APPID3"] = "OPENID5"
+	if openIds, _ = store.OpenIds("UNIONID"); len(openIds) != 2 {
+		t.Errorf("store modified through returned map: %v", openIds)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store.Bind("CONCURRENT", fmt.Sprintf("APPID%d", i), fmt.Sprintf("OPENID%d", i))
+			store.OpenIds("CONCURRENT")
+		}(i)
+	}
+	wg.Wait()
+	if openIds, _ = store.OpenIds("CONCURRENT"); len(openIds) != 10 {
+		t.Errorf("have %d openids, want 10", len(openIds))
+	}
+}